@@ -0,0 +1,18 @@
+// Package b9schema has helpers that combine the reflector and renderer packages.
+package b9schema
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/reflector"
+	"github.com/gitmann/b9schema-reflector-golang/renderer"
+)
+
+// Render reflects a value with a default Reflector and renders it with a named renderer, see renderer.NewRenderer.
+// - Use the reflector and renderer APIs directly for more control.
+func Render(x interface{}, rendererName string, opt *renderer.Options) ([]string, error) {
+	r, err := renderer.NewRenderer(rendererName, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ProcessResult(reflector.NewReflector().DeriveSchema(x))
+}
@@ -0,0 +1,41 @@
+package b9schema
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/reflector"
+	"github.com/gitmann/b9schema-reflector-golang/renderer"
+	"reflect"
+	"testing"
+)
+
+type renderTestStruct struct {
+	Value string
+}
+
+func TestRender(t *testing.T) {
+	gotStrings, err := Render(renderTestStruct{}, "simple", nil)
+	if err != nil {
+		t.Errorf("TEST_FAIL render: unexpected error: %s", err)
+	}
+	wantStrings := []string{
+		`TypeRefs.renderTestStruct:{}`,
+		`TypeRefs.renderTestStruct:{}.Value:string`,
+		`Root.{}:renderTestStruct`,
+	}
+	if !reflect.DeepEqual(gotStrings, wantStrings) {
+		t.Errorf("TEST_FAIL render: simple: got %q, want %q", gotStrings, wantStrings)
+	}
+
+	if _, err := Render(renderTestStruct{}, "unknown", nil); err == nil {
+		t.Errorf("TEST_FAIL render: unknown renderer: got nil error")
+	}
+
+	// Registered renderers are available by name.
+	renderer.RegisterRenderer("test", func(opt *renderer.Options) renderer.Renderer { return renderer.NewJTDRenderer(opt) })
+	defer renderer.RegisterRenderer("test", nil)
+
+	gotStrings, _ = Render(renderTestStruct{}, "test", nil)
+	wantStrings, _ = renderer.NewJTDRenderer(nil).ProcessResult(reflector.NewReflector().DeriveSchema(renderTestStruct{}))
+	if !reflect.DeepEqual(gotStrings, wantStrings) {
+		t.Errorf("TEST_FAIL render: registered: got %q, want %q", gotStrings, wantStrings)
+	}
+}
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+)
+
+// builderDialect is the native dialect for hand-built elements. It matches the dialect used by the reflector.
+const builderDialect = "golang"
+
+// ElementBuilder builds a TypeElement tree by hand for types that cannot be reflected.
+// - Methods return the builder for chaining.
+// - Children are added in call order. Renderers sort children by name.
+type ElementBuilder struct {
+	elem *TypeElement
+}
+
+// NewSchema returns an empty Schema with Root and TypeRefs elements.
+func NewSchema(dialect string) *Schema {
+	return &Schema{
+		Root:     NewRootElement("Root", dialect),
+		TypeRefs: NewRootElement("TypeRefs", dialect),
+	}
+}
+
+// NewElement returns a builder for an element with the given name and generic type.
+func NewElement(name string, genericType generictype.GenericType) *ElementBuilder {
+	// Start from a parent-less element. Parent is set when the element is added to a tree.
+	elem := NewRootElement(name, builderDialect)
+	elem.Type = genericType.String()
+	elem.TypeCategory = genericType.Category().String()
+	elem.NativeDefault().Type = genericType.String()
+
+	return &ElementBuilder{elem: elem}
+}
+
+// NewObject returns a builder for a struct element.
+func NewObject(name string) *ElementBuilder {
+	return NewElement(name, generictype.Struct)
+}
+
+// NewRef returns a builder for a struct element that references the named type.
+func NewRef(name, typeName string) *ElementBuilder {
+	b := NewObject(name)
+	b.elem.TypeRef = typeName
+	b.elem.NativeDefault().TypeRef = typeName
+
+	return b
+}
+
+// NewList returns a builder for a list element with the given item.
+// - The item name is cleared because list items are unnamed.
+func NewList(name string, item *ElementBuilder) *ElementBuilder {
+	b := NewElement(name, generictype.List)
+
+	item.elem.Name = ""
+	b.elem.AddChild(item.elem)
+
+	return b
+}
+
+// Add adds a child element.
+func (b *ElementBuilder) Add(child *ElementBuilder) *ElementBuilder {
+	b.elem.AddChild(child.elem)
+	return b
+}
+
+// Field adds a child element with the given generic type.
+func (b *ElementBuilder) Field(name string, genericType generictype.GenericType) *ElementBuilder {
+	return b.Add(NewElement(name, genericType))
+}
+
+// Ref adds a child element that references the named type.
+func (b *ElementBuilder) Ref(name, typeName string) *ElementBuilder {
+	return b.Add(NewRef(name, typeName))
+}
+
+// List adds a child list element with the given item.
+func (b *ElementBuilder) List(name string, item *ElementBuilder) *ElementBuilder {
+	return b.Add(NewList(name, item))
+}
+
+// Nullable marks the element as nullable.
+func (b *ElementBuilder) Nullable() *ElementBuilder {
+	b.elem.Nullable = true
+	return b
+}
+
+// Element returns the built TypeElement.
+func (b *ElementBuilder) Element() *TypeElement {
+	return b.elem
+}
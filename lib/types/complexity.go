@@ -0,0 +1,60 @@
+package types
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+)
+
+// ComplexityReport summarizes the size of a Schema.
+type ComplexityReport struct {
+	// Types is the number of named types (TypeRefs).
+	Types int
+
+	// Fields is the number of struct fields in the de-referenced root type.
+	Fields int
+
+	// MaxDepth is the maximum nesting depth of the de-referenced root type. The root type has depth 1.
+	MaxDepth int
+
+	// Cycles is the number of cyclical references.
+	Cycles int
+
+	// Errors is the number of elements with errors other than cyclical references.
+	Errors int
+}
+
+// Complexity returns a ComplexityReport for the Schema.
+// - Counts are based on the de-referenced Root so shared types are counted each time they are used.
+func (s *Schema) Complexity() ComplexityReport {
+	report := ComplexityReport{
+		Types: len(s.TypeRefs.Children),
+	}
+
+	var walk func(t *TypeElement, depth int)
+	walk = func(t *TypeElement, depth int) {
+		if depth > report.MaxDepth {
+			report.MaxDepth = depth
+		}
+
+		if t.Name != "" && t.Parent != nil && t.Parent.Type == generictype.Struct.String() {
+			report.Fields++
+		}
+
+		switch t.Error {
+		case "":
+		case CyclicalReferenceErr:
+			report.Cycles++
+		default:
+			report.Errors++
+		}
+
+		for _, childElem := range t.Children {
+			walk(childElem, depth+1)
+		}
+	}
+
+	for _, childElem := range s.Root.Children {
+		walk(childElem, 1)
+	}
+
+	return report
+}
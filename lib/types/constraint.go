@@ -0,0 +1,36 @@
+package types
+
+import (
+	"strings"
+)
+
+const (
+	// ConstraintTypeErr is set when a constraint does not apply to the type of an element, e.g. a pattern on an integer.
+	ConstraintTypeErr = "constraint does not apply to type"
+
+	// ConstraintValueErr is set when a constraint value is not valid, e.g. a minimum that is not a number.
+	ConstraintValueErr = "constraint value is not valid"
+)
+
+// ConstraintKeys are the validation constraints in render order. Keys are the same in struct tags, OpenAPI and JSON Schema.
+// - "minimum" and "maximum" apply to integers and floats. "minLength", "maxLength" and "pattern" apply to strings.
+var ConstraintKeys = []string{"minimum", "maximum", "minLength", "maxLength", "pattern"}
+
+// SetConstraint records a validation constraint for an element.
+// - Values are stored in the native type option named after the capitalized key, e.g. "MinLength".
+func (t *TypeElement) SetConstraint(key, value string) {
+	t.NativeDefault().Options.AddKeyVal(constraintOption(key), value)
+}
+
+// Constraint returns the value of a validation constraint and true if it is set.
+func (t *TypeElement) Constraint(key string) (string, bool) {
+	return t.NativeDefault().Options.Get(constraintOption(key))
+}
+
+// constraintOption returns the native type option of a constraint key. An empty key is returned as is.
+func constraintOption(key string) string {
+	if key == "" {
+		return key
+	}
+	return strings.ToUpper(key[:1]) + key[1:]
+}
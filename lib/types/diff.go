@@ -0,0 +1,124 @@
+package types
+
+import (
+	"sort"
+)
+
+// ChangeKind is the kind of a SchemaChange.
+type ChangeKind int
+
+const (
+	// Added is an element that is only in the new schema.
+	Added ChangeKind = iota
+
+	// Removed is an element that is only in the old schema.
+	Removed
+
+	// TypeChanged is an element with a different type or TypeRef. Children of changed elements are not compared.
+	TypeChanged
+
+	// Renamed is an element with a different JSON name.
+	Renamed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case TypeChanged:
+		return "typeChanged"
+	case Renamed:
+		return "renamed"
+	default:
+		return "unknown"
+	}
+}
+
+// SchemaChange is a difference between two schemas.
+type SchemaChange struct {
+	// Path of the element, see ElementPath. Added elements have the path in the new schema, others in the old schema.
+	Path string
+
+	Kind ChangeKind
+
+	// OldType and NewType are type strings, e.g. "integer" or "struct:User". Added elements have no OldType and removed elements have no NewType.
+	OldType string
+	NewType string
+
+	// OldName and NewName are the JSON names of renamed elements.
+	OldName string
+	NewName string
+}
+
+// DiffSchemas returns the changes from schema a to schema b.
+// - Elements are matched by name. Root and TypeRefs are compared separately.
+// - Matching elements with the same type are compared recursively.
+// - Changes are sorted by path.
+func DiffSchemas(a, b *Schema) []SchemaChange {
+	changes := []SchemaChange{}
+	changes = diffChildren(changes, a.Root, b.Root)
+	changes = diffChildren(changes, a.TypeRefs, b.TypeRefs)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+// diffChildren appends the changes between the children of two elements.
+func diffChildren(changes []SchemaChange, a, b *TypeElement) []SchemaChange {
+	aMap := a.ChildMap()
+	bMap := b.ChildMap()
+
+	names := a.ChildKeys(aMap)
+	for _, name := range b.ChildKeys(bMap) {
+		if aMap[name] == nil {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		aElem, bElem := aMap[name], bMap[name]
+		switch {
+		case bElem == nil:
+			changes = append(changes, SchemaChange{Path: ElementPath(aElem), Kind: Removed, OldType: typeString(aElem)})
+		case aElem == nil:
+			changes = append(changes, SchemaChange{Path: ElementPath(bElem), Kind: Added, NewType: typeString(bElem)})
+		default:
+			changes = diffElements(changes, aElem, bElem)
+		}
+	}
+
+	return changes
+}
+
+// diffElements appends the changes between two elements with the same name.
+func diffElements(changes []SchemaChange, a, b *TypeElement) []SchemaChange {
+	if typeString(a) != typeString(b) {
+		return append(changes, SchemaChange{Path: ElementPath(a), Kind: TypeChanged, OldType: typeString(a), NewType: typeString(b)})
+	}
+
+	if oldName, newName := a.GetNativeType("json").Name, b.GetNativeType("json").Name; oldName != newName {
+		changes = append(changes, SchemaChange{
+			Path:    ElementPath(a),
+			Kind:    Renamed,
+			OldType: typeString(a),
+			NewType: typeString(b),
+			OldName: oldName,
+			NewName: newName,
+		})
+	}
+
+	return diffChildren(changes, a, b)
+}
+
+// typeString returns the type of an element with its TypeRef, e.g. "struct:User".
+func typeString(t *TypeElement) string {
+	if t.TypeRef != "" {
+		return t.Type + ":" + t.TypeRef
+	}
+	return t.Type
+}
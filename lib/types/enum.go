@@ -0,0 +1,50 @@
+package types
+
+import (
+	"encoding/json"
+)
+
+// DefaultEnumErr is set when a default value is not one of the enum values.
+const DefaultEnumErr = "default must be one of the enum values"
+
+// SetEnumValues records the allowed values for an element.
+// - Values are stored as a JSON list in the "Enum" option of the native type.
+func (t *TypeElement) SetEnumValues(values []string) {
+	b, _ := json.Marshal(values)
+	t.NativeDefault().Options.AddKeyVal("Enum", string(b))
+}
+
+// EnumValues returns the allowed values for an element or nil if values are not restricted.
+func (t *TypeElement) EnumValues() []string {
+	val, ok := t.NativeDefault().Options.Get("Enum")
+	if !ok {
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal([]byte(val), &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// SetKeyEnumValues records the allowed keys for a map element.
+// - Keys are stored as a JSON list in the "KeyEnum" option of the native type.
+func (t *TypeElement) SetKeyEnumValues(values []string) {
+	b, _ := json.Marshal(values)
+	t.NativeDefault().Options.AddKeyVal("KeyEnum", string(b))
+}
+
+// KeyEnumValues returns the allowed keys for a map element or nil if keys are not restricted.
+func (t *TypeElement) KeyEnumValues() []string {
+	val, ok := t.NativeDefault().Options.Get("KeyEnum")
+	if !ok {
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal([]byte(val), &values); err != nil {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/json"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+)
+
+// Example values for basic types.
+const (
+	ExampleString   = "string"
+	ExampleDateTime = "2006-01-02T15:04:05Z"
+	ExampleMapKey   = "key"
+)
+
+// ExampleJSON returns an indented JSON example of the Schema root.
+// - Struct fields use their JSON names. Excluded fields and fields with errors other than cycles are skipped.
+// - Lists have one item. Maps have one key.
+// - TypeRefs are resolved. Cyclical references are null.
+func (s *Schema) ExampleJSON() ([]byte, error) {
+	var value interface{}
+	if len(s.Root.Children) > 0 {
+		value = s.exampleOf(s.Root.Children[0], map[string]bool{})
+	}
+
+	return json.MarshalIndent(value, "", "  ")
+}
+
+// exampleOf returns an example value for an element.
+// - seen holds the TypeRefs being resolved to stop cycles.
+func (s *Schema) exampleOf(t *TypeElement, seen map[string]bool) interface{} {
+	if t.Error != "" {
+		return nil
+	}
+
+	if t.TypeRef != "" && len(t.Children) == 0 {
+		refElem := s.TypeRefs.ChildByName(t.TypeRef, nil)
+		if refElem == nil || seen[t.TypeRef] {
+			return nil
+		}
+
+		seen[t.TypeRef] = true
+		defer delete(seen, t.TypeRef)
+
+		return s.exampleOf(refElem, seen)
+	}
+
+	switch t.SchemaType() {
+	case generictype.Struct.String():
+		out := map[string]interface{}{}
+
+		if isMapElement(t) {
+			out[ExampleMapKey] = s.exampleOf(t.Children[0], seen)
+			return out
+		}
+
+		for _, childElem := range t.Children {
+			childJSON := childElem.GetNativeType("json")
+			if childJSON.Include == threeflag.False || (childElem.Error != "" && childElem.Error != CyclicalReferenceErr) {
+				continue
+			}
+
+			out[childJSON.Name] = s.exampleOf(childElem, seen)
+		}
+		return out
+	case generictype.List.String():
+		out := []interface{}{}
+		if len(t.Children) > 0 {
+			out = append(out, s.exampleOf(t.Children[0], seen))
+		}
+		return out
+	case generictype.Boolean.String():
+		return false
+	case generictype.Integer.String():
+		return 0
+	case generictype.Float.String():
+		return 0.0
+	case generictype.String.String():
+		return ExampleString
+	case generictype.DateTime.String():
+		return ExampleDateTime
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// hashOptions are the native options that describe the structure of an element.
+// - Options that depend on a reflected value (e.g. IsNil, Len) or on field order are not included.
+var hashOptions = []string{"Access", "Default", "Enum", "Format", "KeyEnum", "KeyPattern", "MapKeyType", "Maximum", "MaxLength", "Minimum", "MinLength", "Pattern", "Unsigned"}
+
+// Hash returns a hex-encoded SHA-256 hash of the structure of the Schema.
+// - The hash includes names, types, TypeRefs, nullability, errors, JSON names and options, and structural native options.
+// - Children are hashed in name order so reflection order does not matter. Parents and IDs are ignored.
+// - Schemas of the same type have the same hash regardless of the reflected value.
+func (s *Schema) Hash() string {
+	h := sha256.New()
+
+	hashElement(h, s.Root, 0)
+	hashElement(h, s.TypeRefs, 0)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashElement writes the structure of an element and its children to h.
+func hashElement(h hash.Hash, t *TypeElement, depth int) {
+	jsonType := t.GetNativeType("json")
+	_, omitEmpty := jsonType.Options.Get("omitempty")
+
+	fmt.Fprintf(h, "%d|%q|%q|%q|%q|%t|%q|json:%q,%s,%t",
+		depth, t.Name, t.Type, t.TypeCategory, t.TypeRef, t.Nullable, t.Error,
+		jsonType.Name, jsonType.Include, omitEmpty)
+
+	for _, key := range hashOptions {
+		if val, ok := t.NativeDefault().Options.Get(key); ok {
+			fmt.Fprintf(h, "|%s=%q", key, val)
+		}
+	}
+	fmt.Fprintln(h)
+
+	children := append([]*TypeElement{}, t.Children...)
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+
+	for _, childElem := range children {
+		hashElement(h, childElem, depth+1)
+	}
+}
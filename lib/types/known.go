@@ -0,0 +1,24 @@
+package types
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/typecategory"
+)
+
+// SchemaType returns the generic type that renderers map to a schema type.
+// - Known types with their own type, e.g. "duration" from reflector.RegisterKnown, are strings. Their format is the "Format" native option.
+func (t *TypeElement) SchemaType() string {
+	if t.TypeCategory == typecategory.Known.String() && t.Type != generictype.DateTime.String() {
+		return generictype.String.String()
+	}
+	return t.Type
+}
+
+// PathDefault returns the type of an element in path strings, e.g. "{}" for structs.
+// - Known types may set their own with the "PathDefault" native option.
+func (t *TypeElement) PathDefault() string {
+	if pathDefault, ok := t.NativeDefault().Options.Get("PathDefault"); ok {
+		return pathDefault
+	}
+	return generictype.PathDefaultOfType(t.Type)
+}
@@ -0,0 +1,143 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+)
+
+// schemaJSON is the persisted form of a Schema.
+type schemaJSON struct {
+	Root     *elementJSON `json:"root"`
+	TypeRefs *elementJSON `json:"typeRefs"`
+}
+
+// elementJSON is the persisted form of a TypeElement. Parents are rebuilt from Children when the Schema is loaded.
+type elementJSON struct {
+	ID            string                 `json:"id,omitempty"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description,omitempty"`
+	Type          string                 `json:"type"`
+	TypeCategory  string                 `json:"typeCategory,omitempty"`
+	TypeRef       string                 `json:"typeRef,omitempty"`
+	Nullable      bool                   `json:"nullable,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	NativeDialect string                 `json:"nativeDialect"`
+	Native        map[string]*nativeJSON `json:"native,omitempty"`
+	Children      []*elementJSON         `json:"children,omitempty"`
+}
+
+// nativeJSON is the persisted form of a NativeType.
+type nativeJSON struct {
+	Name    string            `json:"name,omitempty"`
+	Type    string            `json:"type,omitempty"`
+	TypeRef string            `json:"typeRef,omitempty"`
+	Include string            `json:"include,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// ToJSON returns the Schema as JSON, including native data of all dialects, so it can be reloaded with SchemaFromJSON.
+// - Use it to persist a Schema and render it later without the Go types that were reflected.
+func (s *Schema) ToJSON() ([]byte, error) {
+	return json.Marshal(&schemaJSON{
+		Root:     elementToJSON(s.Root),
+		TypeRefs: elementToJSON(s.TypeRefs),
+	})
+}
+
+// SchemaFromJSON loads a Schema from JSON created by ToJSON. Parents of all elements are restored.
+func SchemaFromJSON(b []byte) (*Schema, error) {
+	in := &schemaJSON{}
+	if err := json.Unmarshal(b, in); err != nil {
+		return nil, err
+	}
+	if in.Root == nil || in.TypeRefs == nil {
+		return nil, fmt.Errorf("schema JSON must have root and typeRefs")
+	}
+
+	return &Schema{
+		Root:     elementFromJSON(in.Root),
+		TypeRefs: elementFromJSON(in.TypeRefs),
+	}, nil
+}
+
+// elementToJSON converts an element and its children to their persisted form.
+func elementToJSON(t *TypeElement) *elementJSON {
+	out := &elementJSON{
+		ID:            t.ID,
+		Name:          t.Name,
+		Description:   t.Description,
+		Type:          t.Type,
+		TypeCategory:  t.TypeCategory,
+		TypeRef:       t.TypeRef,
+		Nullable:      t.Nullable,
+		Error:         t.Error,
+		NativeDialect: t.NativeDialect,
+		Native:        map[string]*nativeJSON{},
+	}
+
+	for dialect, nativeType := range t.Native {
+		nativeOut := &nativeJSON{
+			Name:    nativeType.Name,
+			Type:    nativeType.Type,
+			TypeRef: nativeType.TypeRef,
+			Options: map[string]string{},
+			Error:   nativeType.Error,
+		}
+		if nativeType.Include != threeflag.Undefined {
+			nativeOut.Include = nativeType.Include.String()
+		}
+		for _, key := range nativeType.Options.Keys() {
+			nativeOut.Options[key], _ = nativeType.Options.Get(key)
+		}
+		out.Native[dialect] = nativeOut
+	}
+
+	for _, childElem := range t.Children {
+		out.Children = append(out.Children, elementToJSON(childElem))
+	}
+
+	return out
+}
+
+// elementFromJSON converts a persisted element and its children to a TypeElement.
+func elementFromJSON(in *elementJSON) *TypeElement {
+	t := &TypeElement{
+		ID:            in.ID,
+		Name:          in.Name,
+		Description:   in.Description,
+		Type:          in.Type,
+		TypeCategory:  in.TypeCategory,
+		TypeRef:       in.TypeRef,
+		Nullable:      in.Nullable,
+		Error:         in.Error,
+		NativeDialect: in.NativeDialect,
+		Native:        map[string]*NativeType{},
+	}
+
+	for dialect, nativeIn := range in.Native {
+		nativeType := NewNativeType(dialect)
+		nativeType.Name = nativeIn.Name
+		nativeType.Type = nativeIn.Type
+		nativeType.TypeRef = nativeIn.TypeRef
+		nativeType.Error = nativeIn.Error
+		switch nativeIn.Include {
+		case threeflag.True.String():
+			nativeType.Include = threeflag.True
+		case threeflag.False.String():
+			nativeType.Include = threeflag.False
+		}
+		for key, val := range nativeIn.Options {
+			nativeType.Options.AddKeyVal(key, val)
+		}
+		t.Native[dialect] = nativeType
+	}
+
+	// AddChild sets the parent of each child.
+	for _, childIn := range in.Children {
+		t.AddChild(elementFromJSON(childIn))
+	}
+
+	return t
+}
@@ -0,0 +1,130 @@
+package types
+
+import (
+	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"strings"
+)
+
+// Schema profiles restrict a Schema to constructs supported by a target format.
+const (
+	ProtobufProfile = "protobuf"
+)
+
+// Profile errors.
+const (
+	UnknownProfileErr     = "unknown profile"
+	AnonymousStructErr    = "anonymous struct not supported"
+	UntypedInterfaceErr   = "untyped interface not supported"
+	NestedListErr         = "list of lists not supported"
+	ListOfMapsErr         = "list of maps not supported"
+	CompoundMapValueErr   = "map value must not be a list or map"
+	InvalidElementTypeErr = "element type not supported"
+)
+
+// SchemaError describes a problem with an element in a Schema.
+type SchemaError struct {
+	// Path is a dot-separated path from the root element.
+	Path  string
+	Error string
+}
+
+func (e SchemaError) String() string {
+	return fmt.Sprintf("%s ERROR:%s", e.Path, e.Error)
+}
+
+// ValidateProfile checks that the Schema only uses constructs supported by the given profile.
+// - TypeRefs are checked once. Root elements that reference a TypeRef are not checked again.
+// - Returns nil if the Schema is valid for the profile.
+func (s *Schema) ValidateProfile(profile string) []SchemaError {
+	var check func(t *TypeElement) string
+
+	switch profile {
+	case ProtobufProfile:
+		check = protobufProfileCheck
+	default:
+		return []SchemaError{{Path: profile, Error: UnknownProfileErr}}
+	}
+
+	var errs []SchemaError
+
+	var walk func(t *TypeElement, skipRefs bool)
+	walk = func(t *TypeElement, skipRefs bool) {
+		if t.Type != generictype.Root.String() {
+			if e := check(t); e != "" {
+				errs = append(errs, SchemaError{Path: ElementPath(t), Error: e})
+			}
+		}
+
+		// TypeRef children are checked in TypeRefs.
+		if skipRefs && t.TypeRef != "" {
+			return
+		}
+
+		childMap := t.ChildMap()
+		for _, childName := range t.ChildKeys(childMap) {
+			walk(childMap[childName], skipRefs)
+		}
+	}
+	walk(s.TypeRefs, false)
+	walk(s.Root, true)
+
+	return errs
+}
+
+// protobufProfileCheck returns an error string if the element cannot be expressed in protobuf.
+func protobufProfileCheck(t *TypeElement) string {
+	if t.Error == NilInterfaceErr {
+		return UntypedInterfaceErr
+	}
+	if t.Error == InvalidKindErr {
+		return InvalidElementTypeErr
+	}
+
+	switch t.SchemaType() {
+	case generictype.Struct.String():
+		// Named structs are messages. Anonymous structs below the top level have no message name.
+		if t.TypeRef == "" && !isMapElement(t) && t.Parent != nil && t.Parent.Type != generictype.Root.String() {
+			return AnonymousStructErr
+		}
+
+		if isMapElement(t) {
+			valueType := t.Children[0].Type
+			if valueType == generictype.List.String() || (valueType == generictype.Struct.String() && isMapElement(t.Children[0])) {
+				return CompoundMapValueErr
+			}
+		}
+
+	case generictype.List.String():
+		for _, childElem := range t.Children {
+			if childElem.Type == generictype.List.String() {
+				return NestedListErr
+			}
+			if childElem.Type == generictype.Struct.String() && isMapElement(childElem) {
+				return ListOfMapsErr
+			}
+		}
+	}
+
+	return ""
+}
+
+// isMapElement returns true if a struct element is a map typed by its values.
+// - Map values are a single unnamed child element. Struct fields are always named.
+func isMapElement(t *TypeElement) bool {
+	return t.Type == generictype.Struct.String() && len(t.Children) == 1 && t.Children[0].Name == ""
+}
+
+// ElementPath returns a dot-separated path of element names from the root.
+// - Unnamed elements (root children, list items, map values) use the path default of their type.
+func ElementPath(t *TypeElement) string {
+	parts := []string{}
+	for e := t; e != nil; e = e.Parent {
+		part := e.Name
+		if part == "" {
+			part = e.PathDefault()
+		}
+		parts = append([]string{part}, parts...)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,20 @@
+package types
+
+// StripNative returns a clone of the Schema without native data of dialects other than keepDialects.
+// - The native dialect (e.g. "golang") holds reflection details such as IsNil, Kind and PkgPath.
+// - It also holds metadata used by renderers (enum values, formats, defaults). Keep it if renderers need the metadata.
+// - Renderers fall back to the element name and TypeRef for dialects that are removed.
+func (s *Schema) StripNative(keepDialects ...string) *Schema {
+	keep := map[string]bool{}
+	for _, dialect := range keepDialects {
+		keep[dialect] = true
+	}
+
+	return s.Transform(func(t *TypeElement) {
+		for dialect := range t.Native {
+			if !keep[dialect] {
+				delete(t.Native, dialect)
+			}
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+)
+
+// Transform returns a clone of the Schema with fn applied to every element.
+// - TypeRefs are visited before Root. Elements are visited before their children, in child name order.
+// - The Root and TypeRefs elements themselves are not passed to fn.
+// - Children added by fn are visited. The original Schema is not changed.
+func (s *Schema) Transform(fn func(t *TypeElement)) *Schema {
+	out := s.Clone()
+
+	var walk func(t *TypeElement)
+	walk = func(t *TypeElement) {
+		if t.Type != generictype.Root.String() {
+			fn(t)
+		}
+
+		childMap := t.ChildMap()
+		for _, childName := range t.ChildKeys(childMap) {
+			walk(childMap[childName])
+		}
+	}
+	walk(out.TypeRefs)
+	walk(out.Root)
+
+	return out
+}
@@ -0,0 +1,4 @@
+package types
+
+// TypeRefConflictErr is set when types from different packages have the same TypeRef name but different shapes.
+const TypeRefConflictErr = "type ref name is used by a different type"
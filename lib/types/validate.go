@@ -0,0 +1,208 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ValidationError is a difference between a value and the Schema it is validated against.
+type ValidationError struct {
+	// Path of the value with JSON names, e.g. "$.owner.tags[1]". "$" is the root value.
+	Path string
+
+	// Expected is the type of the schema element, e.g. "integer".
+	Expected string
+
+	// Actual is the JSON type of the value: "null", "boolean", "number", "string", "object" or "array". Missing fields are "missing".
+	Actual string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: expected %s, got %s", e.Path, e.Expected, e.Actual)
+}
+
+// Validate compares the structure of a value with the Schema and returns the differences.
+// - The value is compared in its JSON form so Go values and decoded JSON payloads are validated the same way.
+// - Struct fields are matched by their JSON names. Required fields must be present. Unknown fields are allowed.
+// - Lists and maps may be null because Go encodes nil slices and maps as null.
+// - Elements with errors and interfaces accept any value.
+// - Errors are sorted by path.
+func (s *Schema) Validate(x interface{}) []ValidationError {
+	errs := []ValidationError{}
+	if len(s.Root.Children) == 0 {
+		return errs
+	}
+
+	b, err := json.Marshal(x)
+	if err != nil {
+		return append(errs, ValidationError{Path: "$", Expected: s.Root.Children[0].Type, Actual: err.Error()})
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return append(errs, ValidationError{Path: "$", Expected: s.Root.Children[0].Type, Actual: err.Error()})
+	}
+
+	errs = s.validateValue(errs, "$", s.Root.Children[0], v)
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Path < errs[j].Path
+	})
+
+	return errs
+}
+
+// validateValue appends the differences between a decoded JSON value and an element.
+func (s *Schema) validateValue(errs []ValidationError, path string, t *TypeElement, v interface{}) []ValidationError {
+	if v == nil && t.Nullable {
+		return errs
+	}
+
+	// TypeRefs without children are defined in TypeRefs.
+	if t.TypeRef != "" && len(t.Children) == 0 {
+		if refElem := s.TypeRefs.ChildByName(t.TypeRef, nil); refElem != nil {
+			t = refElem
+		}
+	}
+
+	if t.Error != "" && t.Error != CyclicalReferenceErr {
+		return errs
+	}
+
+	if v == nil {
+		if t.Type == generictype.List.String() || isMapElement(t) {
+			return errs
+		}
+		return append(errs, ValidationError{Path: path, Expected: t.Type, Actual: "null"})
+	}
+
+	mismatch := ValidationError{Path: path, Expected: t.Type, Actual: jsonTypeOf(v)}
+
+	switch t.SchemaType() {
+	case generictype.Boolean.String():
+		if _, ok := v.(bool); !ok {
+			return append(errs, mismatch)
+		}
+	case generictype.Integer.String():
+		n, ok := v.(json.Number)
+		if !ok {
+			return append(errs, mismatch)
+		}
+		if _, err := n.Int64(); err != nil {
+			if f, err := n.Float64(); err != nil || f != float64(int64(f)) {
+				return append(errs, mismatch)
+			}
+		}
+	case generictype.Float.String():
+		if _, ok := v.(json.Number); !ok {
+			return append(errs, mismatch)
+		}
+	case generictype.String.String():
+		if _, ok := v.(string); !ok {
+			return append(errs, mismatch)
+		}
+	case generictype.DateTime.String():
+		str, ok := v.(string)
+		if !ok {
+			return append(errs, mismatch)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+			return append(errs, mismatch)
+		}
+	case generictype.List.String():
+		items, ok := v.([]interface{})
+		if !ok {
+			return append(errs, mismatch)
+		}
+		if len(t.Children) > 0 {
+			for i, item := range items {
+				errs = s.validateValue(errs, path+"["+strconv.Itoa(i)+"]", t.Children[0], item)
+			}
+		}
+	case generictype.Struct.String():
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return append(errs, mismatch)
+		}
+		errs = s.validateFields(errs, path, t, fields)
+	}
+
+	return errs
+}
+
+// validateFields appends the differences between the fields of a decoded JSON object and a struct element.
+func (s *Schema) validateFields(errs []ValidationError, path string, t *TypeElement, fields map[string]interface{}) []ValidationError {
+	// Maps have one unnamed child for all values.
+	if isMapElement(t) {
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			errs = s.validateValue(errs, path+"."+key, t.Children[0], fields[key])
+		}
+		return errs
+	}
+
+	for _, childElem := range t.Children {
+		jsonType := childElem.GetNativeType("json")
+		if jsonType.Include == threeflag.False || isInlineElement(jsonType) {
+			continue
+		}
+
+		childPath := path + "." + jsonType.Name
+		childVal, ok := fields[jsonType.Name]
+		if !ok {
+			// Interfaces are optional like nullable fields, even nil interfaces whose TypeRef has the error.
+			isInterface := childElem.Type == generictype.Interface.String() || childElem.NativeDefault().Type == "interface"
+			if _, omitEmpty := jsonType.Options.Get("omitempty"); !childElem.Nullable && !isInterface && !omitEmpty && childElem.Error == "" {
+				errs = append(errs, ValidationError{Path: childPath, Expected: childElem.Type, Actual: "missing"})
+			}
+			continue
+		}
+
+		errs = s.validateValue(errs, childPath, childElem, childVal)
+	}
+
+	return errs
+}
+
+// isInlineElement returns true if a field holds the properties of its parent object, e.g. `json:",inline"`.
+func isInlineElement(jsonType *NativeType) bool {
+	for _, option := range []string{"inline", "squash", "remain"} {
+		if _, ok := jsonType.Options.Get(option); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeOf returns the JSON type of a decoded JSON value.
+func jsonTypeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,55 @@
+package types
+
+// Schema variants filter fields by access mode.
+const (
+	RequestVariant  = "request"
+	ResponseVariant = "response"
+)
+
+// Field access modes from the "access" struct tag.
+const (
+	ReadOnlyAccess  = "readOnly"
+	WriteOnlyAccess = "writeOnly"
+)
+
+// Clone returns a deep copy of the Schema.
+func (s *Schema) Clone() *Schema {
+	return &Schema{
+		Root:     s.Root.Copy(),
+		TypeRefs: s.TypeRefs.Copy(),
+	}
+}
+
+// Variant returns a filtered clone of the Schema for the given variant.
+// - request drops readOnly fields (set by the server).
+// - response drops writeOnly fields (accepted but never returned).
+// - Returns nil if the variant is unknown.
+func (s *Schema) Variant(kind string) *Schema {
+	var drop string
+
+	switch kind {
+	case RequestVariant:
+		drop = ReadOnlyAccess
+	case ResponseVariant:
+		drop = WriteOnlyAccess
+	default:
+		return nil
+	}
+
+	out := s.Clone()
+	dropAccess(out.Root, drop)
+	dropAccess(out.TypeRefs, drop)
+
+	return out
+}
+
+// dropAccess recursively removes child elements with the given access mode.
+func dropAccess(t *TypeElement, access string) {
+	for _, childElem := range append([]*TypeElement{}, t.Children...) {
+		if val, ok := childElem.NativeDefault().Options.Get("Access"); ok && val == access {
+			t.RemoveChild(childElem)
+			continue
+		}
+		dropAccess(childElem, access)
+	}
+}
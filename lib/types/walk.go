@@ -0,0 +1,27 @@
+package types
+
+// Walk calls fn for each element of the Schema in depth-first pre-order.
+// - TypeRefs is walked before Root. Both have depth 0 and their children have depth 1.
+// - Children are walked in name order so the traversal is deterministic.
+// - Walk stops at the first error returned by fn and returns it.
+func Walk(s *Schema, fn func(t *TypeElement, depth int) error) error {
+	if err := walkElement(s.TypeRefs, 0, fn); err != nil {
+		return err
+	}
+	return walkElement(s.Root, 0, fn)
+}
+
+// walkElement calls fn for an element and then walks its children.
+func walkElement(t *TypeElement, depth int, fn func(t *TypeElement, depth int) error) error {
+	if err := fn(t, depth); err != nil {
+		return err
+	}
+
+	childMap := t.ChildMap()
+	for _, childName := range t.ChildKeys(childMap) {
+		if err := walkElement(childMap[childName], depth+1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package reflector
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"reflect"
+)
+
+// fieldCacheEntry holds the reflected fields of a struct type.
+type fieldCacheEntry struct {
+	// children are the fields of the first reflected element. They are copied when reused.
+	children []*types.TypeElement
+
+	// exportedFields is the number of exported fields of the type.
+	exportedFields int
+
+	// typeNames are the names of all types in the fields that are checked for cycles: TypeRefs and qualified type names.
+	typeNames []string
+}
+
+// cachedFields returns the cached fields of a type or nil if they cannot be reused.
+// - Fields are not reused if one of their types is an ancestor because reflection would find a cycle.
+// - Fields are not reused with MaxDepth because depth errors depend on where a type is found.
+func (r *Reflector) cachedFields(ancestorTypeRef types.AncestorTypeRef, t reflect.Type) *fieldCacheEntry {
+	if !r.CacheTypes || r.MaxDepth > 0 {
+		return nil
+	}
+
+	entry := r.fieldCache[t]
+	if entry == nil {
+		return nil
+	}
+
+	for _, typeName := range entry.typeNames {
+		if ancestorTypeRef.Contains(typeName) {
+			return nil
+		}
+	}
+	return entry
+}
+
+// cacheFields stores the reflected fields of a struct type.
+// - Types that depend on the value and fields with cyclical references are not cached.
+func (r *Reflector) cacheFields(currentElem *types.TypeElement, t reflect.Type, exportedFields int) {
+	if !r.CacheTypes || r.fieldCache[t] != nil || r.isValueDependent(t) {
+		return
+	}
+
+	entry := &fieldCacheEntry{exportedFields: exportedFields}
+
+	seen := map[string]bool{}
+	var addTypeNames func(elem *types.TypeElement) bool
+	addTypeNames = func(elem *types.TypeElement) bool {
+		if elem.Error == types.CyclicalReferenceErr {
+			return false
+		}
+
+		native := elem.NativeDefault()
+		typeName, _ := native.Options.Get("Type.Name")
+		pkgPath, _ := native.Options.Get("Type.PkgPath")
+		for _, name := range []string{native.TypeRef, pkgPath + "." + typeName} {
+			if name != "" && name != "." && !seen[name] {
+				seen[name] = true
+				entry.typeNames = append(entry.typeNames, name)
+			}
+		}
+
+		for _, childElem := range elem.Children {
+			if !addTypeNames(childElem) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, childElem := range currentElem.Children {
+		if !addTypeNames(childElem) {
+			return
+		}
+		entry.children = append(entry.children, childElem)
+	}
+
+	r.fieldCache[t] = entry
+}
+
+// isValueDependent returns true if the reflected fields of a type can differ by value.
+// - Interfaces are reflected by their dynamic type so any type that contains an interface depends on the value.
+func (r *Reflector) isValueDependent(t reflect.Type) bool {
+	if dependent, ok := r.valueDependent[t]; ok {
+		return dependent
+	}
+
+	dependent := hasInterface(t, map[reflect.Type]bool{})
+	r.valueDependent[t] = dependent
+	return dependent
+}
+
+// hasInterface returns true if a type or one of its exported fields, elements or keys is an interface.
+// - seen holds the types being checked to stop at recursive types.
+func hasInterface(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return hasInterface(t.Elem(), seen)
+	case reflect.Map:
+		return hasInterface(t.Key(), seen) || hasInterface(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" && hasInterface(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
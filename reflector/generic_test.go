@@ -0,0 +1,68 @@
+//go:build go1.18
+
+package reflector
+
+import (
+	"testing"
+)
+
+// Box is a generic type with one field of the type argument.
+type Box[T any] struct {
+	Value T
+}
+
+// genericTestStruct has two instantiations of the same generic type.
+type genericTestStruct struct {
+	Name   Box[string]
+	Count  Box[int]
+	Counts Box[[]*int]
+}
+
+func TestReflector_GenericTypeNames(t *testing.T) {
+	schema := NewReflector().DeriveSchema(genericTestStruct{})
+
+	tests := []struct {
+		typeRef    string
+		goTypeName string
+		valueType  string
+	}{
+		{typeRef: "BoxString", goTypeName: "Box[string]", valueType: "string"},
+		{typeRef: "BoxInt", goTypeName: "Box[int]", valueType: "integer"},
+		{typeRef: "BoxSlicePtrInt", goTypeName: "Box[[]*int]", valueType: "list"},
+	}
+
+	for _, test := range tests {
+		refElem := schema.TypeRefs.ChildByName(test.typeRef, nil)
+		if refElem == nil {
+			t.Errorf("TEST_FAIL generic type names: TypeRef %q not found", test.typeRef)
+			continue
+		}
+		if refElem.Error != "" {
+			t.Errorf("TEST_FAIL generic type names: %s: got error %q", test.typeRef, refElem.Error)
+		}
+		if got, _ := refElem.NativeDefault().Options.Get("GoTypeName"); got != test.goTypeName {
+			t.Errorf("TEST_FAIL generic type names: %s: GoTypeName: got %q, want %q", test.typeRef, got, test.goTypeName)
+		}
+		if got := refElem.ChildByName("Value", nil).Type; got != test.valueType {
+			t.Errorf("TEST_FAIL generic type names: %s: Value: got type %q, want %q", test.typeRef, got, test.valueType)
+		}
+	}
+}
+
+func TestGenericTypeName(t *testing.T) {
+	tests := map[string]string{
+		"Box":                                 "Box",
+		"Box[string]":                         "BoxString",
+		"Pair[string,int]":                    "PairStringInt",
+		"Box[example.com/pkg.User]":           "BoxUser",
+		"Box[example.com/my-pkg.v2/api.User]": "BoxUser",
+		"Box[map[string]*example.com/v2.Tag]": "BoxMapStringPtrTag",
+		"Box[[]Box[float64]]":                 "BoxSliceBoxFloat64",
+	}
+
+	for name, want := range tests {
+		if got := genericTypeName(name); got != want {
+			t.Errorf("TEST_FAIL genericTypeName(%q): got %q, want %q", name, got, want)
+		}
+	}
+}
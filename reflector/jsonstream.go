@@ -0,0 +1,233 @@
+package reflector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"github.com/gitmann/b9schema-reflector-golang/lib/util"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// JSONTypeConflictErr is set for JSON values that have different types in different places of a stream.
+	JSONTypeConflictErr = "conflicting JSON types"
+)
+
+// jsonShape is the merged structure of JSON values.
+// - Only the structure is kept so memory depends on the number of distinct fields, not on the size of the input.
+type jsonShape struct {
+	// kind is one of: object, array, string, number, boolean, null
+	kind string
+
+	// fields of an object by key.
+	fields map[string]*jsonShape
+
+	// items is the merged shape of all array items. nil if no items were found.
+	items *jsonShape
+
+	// conflicts are the other kinds found for the same value.
+	conflicts []string
+
+	// nullable is set if the value was null in some places and had another kind in others.
+	nullable bool
+}
+
+// DeriveSchemaFromJSONStream builds a schema from a stream of JSON values, e.g. a large JSON document or NDJSON.
+// - Values are read token by token and merged into one structure without decoding them.
+// - All values of the stream are merged: object fields from every object, items from every array.
+// - The merged structure is reflected like DeriveSchema of a decoded JSON value, e.g. numbers are floats.
+// - Values with different types (other than null) have a JSONTypeConflictErr error.
+// - Only values that are null in some places are nullable.
+func (r *Reflector) DeriveSchemaFromJSONStream(rd io.Reader) (*types.Schema, error) {
+	dec := json.NewDecoder(rd)
+
+	var shape *jsonShape
+	for {
+		next, err := decodeJSONShape(dec)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		shape = mergeJSONShape(shape, next)
+	}
+
+	if shape == nil {
+		return nil, fmt.Errorf("no JSON values found")
+	}
+
+	schema := r.DeriveSchema(shape.value())
+
+	// Conflicts and nulls cannot be expressed with a value so they are added to the reflected elements.
+	if len(schema.Root.Children) > 0 {
+		markJSONShape(schema.Root.Children[len(schema.Root.Children)-1], shape)
+	}
+
+	return schema, nil
+}
+
+// decodeJSONShape reads the next JSON value from the decoder and returns its shape.
+func decodeJSONShape(dec *json.Decoder) (*jsonShape, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch val := tok.(type) {
+	case json.Delim:
+		if val == '{' {
+			shape := &jsonShape{kind: "object", fields: map[string]*jsonShape{}}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				child, err := decodeJSONShape(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				key := keyTok.(string)
+				shape.fields[key] = mergeJSONShape(shape.fields[key], child)
+			}
+			_, err = dec.Token()
+			return shape, err
+		}
+
+		shape := &jsonShape{kind: "array"}
+		for dec.More() {
+			item, err := decodeJSONShape(dec)
+			if err != nil {
+				return nil, err
+			}
+			shape.items = mergeJSONShape(shape.items, item)
+		}
+		_, err = dec.Token()
+		return shape, err
+	case string:
+		return &jsonShape{kind: "string"}, nil
+	case float64:
+		return &jsonShape{kind: "number"}, nil
+	case bool:
+		return &jsonShape{kind: "boolean"}, nil
+	default:
+		return &jsonShape{kind: "null"}, nil
+	}
+}
+
+// mergeJSONShape merges two shapes and returns the merged shape.
+// - null takes the type of the other shape, which becomes nullable.
+// - Shapes with different kinds keep the first kind and record the other kind as a conflict.
+func mergeJSONShape(a, b *jsonShape) *jsonShape {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.kind == "null" {
+		if b.kind != "null" {
+			b.nullable = true
+		}
+		return b
+	}
+	if b.kind == "null" {
+		a.nullable = true
+		return a
+	}
+
+	a.nullable = a.nullable || b.nullable
+
+	if a.kind != b.kind {
+		a.addConflict(b.kind)
+		for _, kind := range b.conflicts {
+			a.addConflict(kind)
+		}
+		return a
+	}
+
+	for key, field := range b.fields {
+		a.fields[key] = mergeJSONShape(a.fields[key], field)
+	}
+	a.items = mergeJSONShape(a.items, b.items)
+	for _, kind := range b.conflicts {
+		a.addConflict(kind)
+	}
+
+	return a
+}
+
+// addConflict records a conflicting kind once.
+func (s *jsonShape) addConflict(kind string) {
+	if kind == s.kind {
+		return
+	}
+	for _, k := range s.conflicts {
+		if k == kind {
+			return
+		}
+	}
+	s.conflicts = append(s.conflicts, kind)
+}
+
+// value returns a small JSON value with the structure of the shape, like a value decoded by encoding/json.
+// - Objects have one key per field and arrays have one item.
+func (s *jsonShape) value() interface{} {
+	switch s.kind {
+	case "object":
+		out := map[string]interface{}{}
+		for key, field := range s.fields {
+			out[key] = field.value()
+		}
+		return out
+	case "array":
+		if s.items == nil {
+			return []interface{}{}
+		}
+		return []interface{}{s.items.value()}
+	case "string":
+		return ""
+	case "number":
+		return 0.0
+	case "boolean":
+		return false
+	default:
+		return nil
+	}
+}
+
+// markJSONShape sets JSONTypeConflictErr on elements whose shape has conflicts and sets Nullable from the shape.
+func markJSONShape(currentElem *types.TypeElement, shape *jsonShape) {
+	if currentElem == nil || shape == nil {
+		return
+	}
+
+	if len(shape.conflicts) > 0 && currentElem.Error == "" {
+		kinds := append([]string{shape.kind}, shape.conflicts...)
+		sort.Strings(kinds)
+
+		currentElem.Error = JSONTypeConflictErr
+		currentElem.NativeDefault().Error = fmt.Sprintf("%s: %s", JSONTypeConflictErr, strings.Join(kinds, ","))
+	}
+
+	// Decoded values are interfaces, which are always nullable. Only values that were null are kept nullable.
+	if shape.kind != "null" {
+		currentElem.Nullable = shape.nullable
+	}
+
+	switch shape.kind {
+	case "object":
+		// Map keys are reflected with capitalized names.
+		for key, field := range shape.fields {
+			markJSONShape(currentElem.ChildByName(util.Capitalize(key), nil), field)
+		}
+	case "array":
+		if len(currentElem.Children) > 0 {
+			markJSONShape(currentElem.Children[0], shape.items)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package reflector
+
+// ReflectorOption configures a Reflector in NewReflector.
+// - Options are stored on the Reflector so they are kept by Reset.
+type ReflectorOption func(r *Reflector)
+
+// WithMaxDepth sets MaxDepth.
+func WithMaxDepth(n int) ReflectorOption {
+	return func(r *Reflector) {
+		r.MaxDepth = n
+	}
+}
+
+// WithRespectMarshalers sets RespectMarshalers.
+func WithRespectMarshalers(value bool) ReflectorOption {
+	return func(r *Reflector) {
+		r.RespectMarshalers = value
+	}
+}
+
+// WithSQLNullTypes sets SQLNullTypes.
+func WithSQLNullTypes(value bool) ReflectorOption {
+	return func(r *Reflector) {
+		r.SQLNullTypes = value
+	}
+}
+
+// WithKnownType reflects a named type as a string with a format, like time.Time or url.URL.
+// - The type is identified by its package path and name, e.g. "github.com/google/uuid" and "UUID".
+// - slug is the string format, e.g. "uuid". An empty slug is a plain string.
+func WithKnownType(pkgPath, name, slug string) ReflectorOption {
+	return func(r *Reflector) {
+		if r.KnownTypes == nil {
+			r.KnownTypes = map[string]string{}
+		}
+		r.KnownTypes[pkgPath+"."+name] = slug
+	}
+}
@@ -1,6 +1,10 @@
 package reflector
 
 import (
+	"context"
+	"database/sql"
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
 	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
@@ -8,23 +12,214 @@ import (
 	"github.com/gitmann/b9schema-reflector-golang/lib/idgen"
 	"github.com/gitmann/b9schema-reflector-golang/lib/types"
 	"github.com/gitmann/b9schema-reflector-golang/lib/util"
+	"go/token"
+	"net/url"
 	"reflect"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode"
 )
 
 const (
 	NATIVE_DIALECT = "golang"
 )
 
+const (
+	// JSONShapeErr is set for elements with an unknown "jsonshape" struct tag value.
+	JSONShapeErr = "jsonshape must be string, object or array"
+
+	// MaxDepthErr is set for elements that are nested deeper than MaxDepth.
+	MaxDepthErr = "maximum depth exceeded"
+)
+
+// EnumValues is implemented by named types with a fixed set of values.
+type EnumValues interface {
+	EnumValues() []string
+}
+
+var (
+	enumValuesType = reflect.TypeOf((*EnumValues)(nil)).Elem()
+	contextType    = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+
+	binaryMarshalerType = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// knownStringFormats maps known types that serialize as strings to their string format.
+var knownStringFormats = map[reflect.Type]string{
+	reflect.TypeOf(url.URL{}): "uri",
+}
+
+// knownType is a type registered with RegisterKnown.
+type knownType struct {
+	slug        string
+	pathDefault string
+}
+
+// knownTypes maps qualified type names (PkgPath.Name) of registered known types. Registration may happen while types are reflected.
+var (
+	knownTypesMu sync.RWMutex
+	knownTypes   = map[string]knownType{
+		// Durations are known types like time.Time. Renderers emit strings with the custom "duration" format.
+		"time.Duration": {slug: "duration", pathDefault: "duration"},
+	}
+)
+
+// RegisterKnown reflects a named type as a known type like time.Time, e.g. decimal.Decimal or civil.Date, in all Reflectors.
+// - The type is identified by its package path and name, e.g. "github.com/shopspring/decimal" and "Decimal".
+// - slug is the type of its elements, e.g. "decimal". Renderers emit known types as strings with the slug as their format.
+// - pathDefault is the type in paths of the simple renderer. An empty pathDefault is the slug.
+// - Registering a type again with a different slug or pathDefault panics.
+func RegisterKnown(pkgPath, typeName, slug, pathDefault string) {
+	key := pkgPath + "." + typeName
+	known := knownType{slug: slug, pathDefault: pathDefault}
+
+	knownTypesMu.Lock()
+	defer knownTypesMu.Unlock()
+
+	if existing, ok := knownTypes[key]; ok && existing != known {
+		panic(fmt.Sprintf("known type %q is already registered as %q", key, existing.slug))
+	}
+	knownTypes[key] = known
+}
+
+// knownTypeOf returns the registered known type of a named type and true if the type is registered.
+func knownTypeOf(t reflect.Type) (knownType, bool) {
+	if t.Name() == "" {
+		return knownType{}, false
+	}
+
+	knownTypesMu.RLock()
+	defer knownTypesMu.RUnlock()
+
+	known, ok := knownTypes[t.PkgPath()+"."+t.Name()]
+	return known, ok
+}
+
+// sqlNullTypes maps database/sql null wrappers to the generic types of their values for SQLNullTypes.
+var sqlNullTypes = map[reflect.Type]generictype.GenericType{
+	reflect.TypeOf(sql.NullString{}):  generictype.String,
+	reflect.TypeOf(sql.NullInt64{}):   generictype.Integer,
+	reflect.TypeOf(sql.NullInt32{}):   generictype.Integer,
+	reflect.TypeOf(sql.NullFloat64{}): generictype.Float,
+	reflect.TypeOf(sql.NullBool{}):    generictype.Boolean,
+	reflect.TypeOf(sql.NullTime{}):    generictype.DateTime,
+}
+
 // Reflector provides functions to build type and values from a Go value.
 type Reflector struct {
 	// Keep track of refs found during parsing.
 	Schema *types.Schema
+
+	// AllowEmptyStruct reflects struct{} as an empty object instead of an error.
+	AllowEmptyStruct bool
+
+	// AllowNonStringMapKeys reflects maps with integer, float or bool keys as objects instead of an error.
+	// - Keys are stringified, e.g. map[int]string{1: "one"} has key "1".
+	// - The generic type of the key is recorded in the "MapKeyType" option.
+	AllowNonStringMapKeys bool
+
+	// BinaryMarshalerAsBytes reflects types that implement encoding.BinaryMarshaler as byte strings instead of their Go type.
+	// - Byte strings are strings with a "Format" option of "byte".
+	BinaryMarshalerAsBytes bool
+
+	// IncludeGetterMethods reflects exported getter methods of structs as read-only fields named after the method.
+	// - Getters have no arguments and return one basic or known value, e.g. "func (u User) FullName() string".
+	// - String and Error are skipped. Fields with the same name as a getter take precedence.
+	IncludeGetterMethods bool
+
+	// RuneAsString reflects rune slices ([]rune) as strings instead of integer lists.
+	// - rune is an alias of int32 so this applies to all int32 slices.
+	RuneAsString bool
+
+	// RespectMarshalers reflects types that implement json.Marshaler or encoding.TextMarshaler as strings instead of their Go type.
+	// - The detected interface is recorded in the "Marshaler" option. Default is true in NewReflector.
+	// - A "jsonshape" tag takes precedence over the marshaler.
+	RespectMarshalers bool
+
+	// KnownTypes maps qualified type names (PkgPath.Name) to a string format. Known types are reflected as formatted strings.
+	// - An empty format is a plain string. See WithKnownType.
+	KnownTypes map[string]string
+
+	// MaxDepth is the maximum nesting depth of elements. 0 is unlimited.
+	// - The first element below the root has depth 1. Pointers and interfaces are not levels of their own.
+	// - Elements deeper than MaxDepth have a MaxDepthErr error and their children are not reflected.
+	MaxDepth int
+
+	// RecordInterfaceAsUnion reflects interfaces with implementations from RegisterInterfaceImpl as unions of the implementations.
+	// - Each implementation is an unnamed child of the interface element. The "Union" option of the element is "oneOf".
+	// - Unions do not depend on the value so nil interfaces are unions too.
+	// - Interfaces without registered implementations are reflected by their value.
+	RecordInterfaceAsUnion bool
+
+	// RootName is the name of the root element. Renderers use it as a title for the root type.
+	RootName string
+
+	// Strictness controls how problems are handled during reflection. Default is Normal.
+	Strictness Strictness
+
+	// OnlyExportedRefs inlines unexported named types instead of creating TypeRefs for them.
+	// - Consumers of a public API cannot reference unexported types.
+	// - Cycles through unexported types have a cyclical reference error because there is no TypeRef to refer to.
+	OnlyExportedRefs bool
+
+	// QualifyTypeRefs disambiguates TypeRef names for types with the same name in different packages.
+	// - The first type found keeps its plain name.
+	// - Clashing types are prefixed with package path segments, adding segments only until the name is unique.
+	QualifyTypeRefs bool
+
+	// CacheTypes reuses the reflected fields of struct types that were already reflected instead of walking them again.
+	// - Types with interfaces are not cached because their fields depend on the value.
+	// - Reused fields have the native options of the first value, e.g. IsZero and Len.
+	// - The cache is cleared by Reset. Change other options only after Reset.
+	CacheTypes bool
+
+	// SQLNullTypes reflects database/sql null wrappers, e.g. sql.NullString, as nullable values of the wrapped type instead of structs.
+	// - The wrappers are not TypeRefs. Their Value and Valid fields are not reflected.
+	SQLNullTypes bool
+
+	// interfaceImpls maps interface type names to their implementations for RecordInterfaceAsUnion.
+	interfaceImpls map[string][]interface{}
+
+	// enums maps type names to their allowed values from RegisterEnum.
+	enums map[string][]string
+
+	// fieldCache holds the reflected fields of struct types for CacheTypes.
+	fieldCache map[reflect.Type]*fieldCacheEntry
+
+	// valueDependent caches whether a type contains an interface, see isValueDependent.
+	valueDependent map[reflect.Type]bool
+
+	// typeRefNames maps a qualified type (PkgPath.Name) to its TypeRef name.
+	typeRefNames map[string]string
+
+	// typeRefOwners maps a TypeRef name to the qualified type that uses it.
+	typeRefOwners map[string]string
+
+	// ctx is checked during reflection to allow cancellation. Set by DeriveSchemaWithContext.
+	ctx context.Context
+
+	// ctxErr holds the context error that aborted reflection.
+	ctxErr error
+
+	// strictErr holds the first element error found in Strict mode.
+	strictErr error
 }
 
-func NewReflector() *Reflector {
-	r := &Reflector{}
+// NewReflector returns a Reflector with default settings changed by opts.
+func NewReflector(opts ...ReflectorOption) *Reflector {
+	r := &Reflector{
+		RespectMarshalers: true,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
 
 	r.Reset()
 
@@ -40,10 +235,45 @@ func (r *Reflector) Reset() *Reflector {
 		TypeRefs: types.NewRootElement("TypeRefs", NATIVE_DIALECT),
 	}
 
+	r.typeRefNames = map[string]string{}
+	r.typeRefOwners = map[string]string{}
+
+	r.fieldCache = map[reflect.Type]*fieldCacheEntry{}
+	r.valueDependent = map[reflect.Type]bool{}
+
+	r.strictErr = nil
+
 	// Return *Reflector for chaining.
 	return r
 }
 
+// RegisterInterfaceImpl registers implementations of an interface for RecordInterfaceAsUnion.
+// - ifaceName is the name of the interface type, e.g. "Shape".
+// - impls are values of the implementing types, e.g. &Circle{}. They are reflected in the order given.
+// - Registrations are kept by Reset.
+func (r *Reflector) RegisterInterfaceImpl(ifaceName string, impls ...interface{}) {
+	if r.interfaceImpls == nil {
+		r.interfaceImpls = map[string][]interface{}{}
+	}
+	r.interfaceImpls[ifaceName] = append(r.interfaceImpls[ifaceName], impls...)
+}
+
+// RegisterEnum registers the allowed values of a named type, e.g. the constants of `type Status string`.
+// - value is a value of the type, e.g. Status(""). Elements of the type have the allowed values as enum values.
+// - allowed values are converted to strings with fmt.Sprint.
+// - Registered values take precedence over the EnumValues interface. Registrations are kept by Reset.
+func (r *Reflector) RegisterEnum(value interface{}, allowed ...interface{}) {
+	if r.enums == nil {
+		r.enums = map[string][]string{}
+	}
+
+	values := make([]string, 0, len(allowed))
+	for _, allowedVal := range allowed {
+		values = append(values, fmt.Sprint(allowedVal))
+	}
+	r.enums[reflect.TypeOf(value).Name()] = values
+}
+
 // DeriveSchema builds a reflector list of elements from the given interface.
 func (r *Reflector) DeriveSchema(x interface{}) *types.Schema {
 	if r.Schema == nil {
@@ -51,11 +281,86 @@ func (r *Reflector) DeriveSchema(x interface{}) *types.Schema {
 	}
 
 	// Start recursive reflection.
-	r.reflectTypeImpl(types.NewAncestorTypeRef(), r.Schema.Root.NewChild(""), reflect.ValueOf(x), nil)
+	r.reflectTypeImpl(types.NewAncestorTypeRef(), r.Schema.Root.NewChild(r.RootName), reflect.ValueOf(x), nil)
 
 	return r.Schema
 }
 
+// DeriveSchemaFromType is like DeriveSchema for the zero value of a type.
+// - Use it when only the reflect.Type is known, e.g. from a registry, so callers do not need an instance.
+func (r *Reflector) DeriveSchemaFromType(t reflect.Type) *types.Schema {
+	if r.Schema == nil {
+		r.Reset()
+	}
+
+	// A nil type is an invalid value like DeriveSchema(nil).
+	var v reflect.Value
+	if t != nil {
+		v = reflect.New(t).Elem()
+	}
+
+	// Start recursive reflection.
+	r.reflectTypeImpl(types.NewAncestorTypeRef(), r.Schema.Root.NewChild(r.RootName), v, nil)
+
+	return r.Schema
+}
+
+// DeriveSchemaWithContext is like DeriveSchema but stops reflection when ctx is cancelled.
+// - If ctx is cancelled, the context error is returned and the schema is nil.
+// - In Strict mode, the first element error is returned and the schema is nil.
+func (r *Reflector) DeriveSchemaWithContext(ctx context.Context, x interface{}) (*types.Schema, error) {
+	r.ctx = ctx
+	r.ctxErr = nil
+	defer func() {
+		r.ctx = nil
+	}()
+
+	schema := r.DeriveSchema(x)
+	if r.ctxErr != nil {
+		return nil, r.ctxErr
+	}
+	if r.strictErr != nil {
+		return nil, r.strictErr
+	}
+
+	return schema, nil
+}
+
+// DeriveFuncSchema builds request and response schemas from the signature of an RPC-style handler.
+// - The request is the first input parameter that is not a context.Context.
+// - The response is the first output that is not an error.
+// - Schemas are nil if the function has no request or response.
+// - The Reflector is reset before each schema.
+func (r *Reflector) DeriveFuncSchema(fn interface{}) (req, resp *types.Schema, err error) {
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return nil, nil, fmt.Errorf("fn must be a function not %T", fn)
+	}
+
+	for i := 0; i < fnType.NumIn(); i++ {
+		if fnType.In(i) != contextType {
+			req = r.deriveTypeSchema(fnType.In(i))
+			break
+		}
+	}
+
+	for i := 0; i < fnType.NumOut(); i++ {
+		if fnType.Out(i) != errorType {
+			resp = r.deriveTypeSchema(fnType.Out(i))
+			break
+		}
+	}
+
+	return req, resp, nil
+}
+
+// deriveTypeSchema builds a new schema from the zero value of a type.
+func (r *Reflector) deriveTypeSchema(t reflect.Type) *types.Schema {
+	r.Reset()
+	r.reflectTypeImpl(types.NewAncestorTypeRef(), r.Schema.Root.NewChild(r.RootName), reflect.New(t).Elem(), nil)
+	return r.Schema
+}
+
 // reflectTypeImpl is a recursive function to reflect Go values.
 //
 // Args:
@@ -73,6 +378,22 @@ func (r *Reflector) reflectTypeImpl(ancestorTypeRef types.AncestorTypeRef, curre
 		panic("currentElem cannot be nil")
 	}
 
+	// Stop reflection if the context was cancelled or a strict error was found.
+	if r.ctxErr != nil || r.strictErr != nil {
+		return
+	}
+	if r.ctx != nil {
+		if err := r.ctx.Err(); err != nil {
+			r.ctxErr = err
+			return
+		}
+	}
+
+	// In Strict mode, the first element error stops reflection.
+	if r.Strictness == Strict {
+		defer r.checkStrict(currentElem)
+	}
+
 	// Create temporary list for named type refs.
 	refList := types.NewTypeList()
 	refList.Push(currentElem)
@@ -110,6 +431,12 @@ func (r *Reflector) reflectTypeImpl(ancestorTypeRef types.AncestorTypeRef, curre
 		}
 	}
 
+	// Stop descending into deeply nested values.
+	if r.MaxDepth > 0 && elementDepth(currentElem) > r.MaxDepth {
+		currentElem.Error = MaxDepthErr
+		return
+	}
+
 	// Capture Go-specific attributes common to all types.
 	native.Options.AddBool("IsZero", v.IsZero())
 	native.Options.AddBool("IsValid", v.IsValid())
@@ -120,8 +447,24 @@ func (r *Reflector) reflectTypeImpl(ancestorTypeRef types.AncestorTypeRef, curre
 	native.Options.AddKeyVal("Type.PkgPath", v.Type().PkgPath())
 
 	// If type.Name differs from type.Kind, element is a TypeRef.
-	if v.Type().Name() != v.Type().Kind().String() {
-		currentElem.TypeRef = v.Type().Name()
+	// - With OnlyExportedRefs, unexported types are inlined. Their qualified names are still tracked to detect cycles.
+	if v.Type().Name() != v.Type().Kind().String() && r.OnlyExportedRefs && !token.IsExported(v.Type().Name()) {
+		if ancestorTypeRef.Contains(v.Type().PkgPath() + "." + v.Type().Name()) {
+			currentElem.Error = types.CyclicalReferenceErr
+			return
+		}
+		ancestorTypeRef.Add(v.Type().PkgPath() + "." + v.Type().Name())
+	} else if v.Type().Name() != v.Type().Kind().String() {
+		// Instantiated generic types have type arguments in their name, e.g. "Box[string]". TypeRefs use an identifier, e.g. "BoxString".
+		// - The Go type name is kept in the GoTypeName native option.
+		currentElem.TypeRef = genericTypeName(v.Type().Name())
+		if currentElem.TypeRef != v.Type().Name() {
+			native.Options.AddKeyVal("GoTypeName", v.Type().Name())
+		}
+
+		if r.QualifyTypeRefs {
+			currentElem.TypeRef = r.typeRefName(v.Type())
+		}
 
 		native.TypeRef = currentElem.TypeRef
 		native.Options.AddKeyVal("TypeRef", currentElem.TypeRef)
@@ -134,11 +477,91 @@ func (r *Reflector) reflectTypeImpl(ancestorTypeRef types.AncestorTypeRef, curre
 		ancestorTypeRef.Add(currentElem.TypeRef)
 	}
 
+	// Types with a custom MarshalJSON may declare the shape that they emit with a "jsonshape" tag.
+	// - Pointers and interfaces are resolved first to keep nullability.
+	if genericType.Category() != typecategory.Reference {
+		if shape, ok := native.Options.Get("JSONShape"); ok {
+			// Field shapes are not TypeRefs because other fields of the same type may not have a shape.
+			currentElem.TypeRef = ""
+			native.TypeRef = ""
+			r.applyJSONShape(currentElem, shape)
+			return
+		}
+		if shape := jsonShapeOf(v.Type()); shape != "" {
+			r.applyJSONShape(currentElem, shape)
+			r.addTypeRef(currentElem)
+			return
+		}
+	}
+
+	if nullType, ok := sqlNullTypes[v.Type()]; ok && r.SQLNullTypes {
+		// SQL null wrappers are nullable values. Like known types, they are not TypeRefs.
+		genericType = nullType
+		currentElem.Type = genericType.String()
+		currentElem.TypeCategory = genericType.Category().String()
+		currentElem.TypeRef = ""
+		currentElem.Nullable = true
+		native.TypeRef = ""
+	} else if known, ok := knownTypeOf(v.Type()); ok {
+		// Registered known types have their own type. Like other known types, they are not TypeRefs.
+		currentElem.Type = known.slug
+		currentElem.TypeCategory = typecategory.Known.String()
+		currentElem.TypeRef = ""
+		native.TypeRef = ""
+		native.Options.AddKeyVal("Format", known.slug)
+		if known.pathDefault != "" {
+			native.Options.AddKeyVal("PathDefault", known.pathDefault)
+		}
+		return
+	} else if format, ok := r.knownStringFormat(v.Type()); ok {
+		// Known string types are formatted strings. Like other known types, they are not TypeRefs.
+		genericType = generictype.String
+		currentElem.Type = genericType.String()
+		currentElem.TypeCategory = genericType.Category().String()
+		currentElem.TypeRef = ""
+		native.TypeRef = ""
+		if format != "" {
+			native.Options.AddKeyVal("Format", format)
+		}
+	} else if format, ok := r.byteStringFormat(v.Type()); ok {
+		// Byte and rune slices are strings on the wire. Named slices remain TypeRefs.
+		genericType = generictype.String
+		currentElem.Type = genericType.String()
+		currentElem.TypeCategory = genericType.Category().String()
+		if format != "" {
+			native.Options.AddKeyVal("Format", format)
+		}
+		if format == "byte" {
+			native.Options.AddKeyVal("Encoding", "base64")
+		}
+	} else if marshaler, ok := r.stringMarshalerOf(v.Type(), genericType); ok {
+		// Custom marshalers are strings on the wire. Their fields are not reflected.
+		genericType = generictype.String
+		currentElem.Type = genericType.String()
+		currentElem.TypeCategory = genericType.Category().String()
+		native.Options.AddKeyVal("Marshaler", marshaler)
+	} else if r.BinaryMarshalerAsBytes && genericType != generictype.DateTime && genericType.Category() != typecategory.Reference {
+		// Binary marshalers are byte strings regardless of their Go type. References are resolved first to keep nullability.
+		if implements(v.Type(), binaryMarshalerType) {
+			genericType = generictype.String
+			currentElem.Type = genericType.String()
+			currentElem.TypeCategory = genericType.Category().String()
+			native.Options.AddKeyVal("Format", "byte")
+			native.Options.AddKeyVal("Encoding", "base64")
+		}
+	}
+
 	// Capture attributes that differ by type.
 	unhandledType := false
 	switch genericType.Category() {
 	case typecategory.Basic:
-		// Basic types are already handled by the default operations above. Nothing else to do here.
+		// Basic types are already handled by the default operations above. Only enums and unsigned integers need more.
+		if enumValues, ok := r.enumValuesOf(v.Type()); ok {
+			currentElem.SetEnumValues(enumValues)
+		}
+		if genericType == generictype.Integer && isUnsigned(v.Kind()) {
+			native.Options.AddBool("Unsigned", true)
+		}
 	case typecategory.Known:
 		// Known types are already handled by the default operations above. However, TypeRef should be removed.
 		currentElem.TypeRef = ""
@@ -178,6 +601,471 @@ func (r *Reflector) reflectTypeImpl(ancestorTypeRef types.AncestorTypeRef, curre
 	r.addTypeRef(currentElem)
 }
 
+// elementDepth returns the nesting depth of an element. The first element below a root element has depth 1.
+func elementDepth(currentElem *types.TypeElement) int {
+	depth := 0
+	for elem := currentElem; elem.Parent != nil; elem = elem.Parent {
+		depth++
+	}
+	return depth
+}
+
+// isUnsigned returns true for unsigned integer kinds.
+func isUnsigned(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkStrict records the first element error in Strict mode.
+func (r *Reflector) checkStrict(currentElem *types.TypeElement) {
+	if r.strictErr == nil && currentElem.Error != "" && currentElem.Error != types.CyclicalReferenceErr {
+		r.strictErr = fmt.Errorf("%s ERROR:%s", types.ElementPath(currentElem), currentElem.Error)
+	}
+}
+
+// knownStringFormat returns the string format of a known string type and true if the type is known.
+// - Types in KnownTypes take precedence over built-in known types.
+func (r *Reflector) knownStringFormat(t reflect.Type) (string, bool) {
+	if format, ok := r.KnownTypes[t.PkgPath()+"."+t.Name()]; ok && t.Name() != "" {
+		return format, true
+	}
+
+	format, ok := knownStringFormats[t]
+	return format, ok
+}
+
+// byteStringFormat classifies byte and rune lists that are serialized as strings.
+// Returns the string format and true if the type is a string, following encoding/json:
+// - []byte, []uint8 and named byte slices are base64 strings with format "byte". The caller records Encoding=base64.
+// - []rune is a plain string with no format if RuneAsString is set.
+// - Arrays such as [16]byte are encoded as lists of numbers so they remain integer lists.
+func (r *Reflector) byteStringFormat(t reflect.Type) (string, bool) {
+	if t.Kind() != reflect.Slice {
+		return "", false
+	}
+
+	switch t.Elem().Kind() {
+	case reflect.Uint8:
+		return "byte", true
+	case reflect.Int32:
+		return "", r.RuneAsString
+	default:
+		return "", false
+	}
+}
+
+// jsonShapeOf returns the "jsonshape" tag of a blank field of a struct type or "" if there is none.
+// - The blank field declares the shape for every use of the type, e.g. "_ struct{} `jsonshape:\"string\"`".
+func jsonShapeOf(t reflect.Type) string {
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if structField := t.Field(i); structField.Name == "_" {
+			if shape := structField.Tag.Get("jsonshape"); shape != "" {
+				return shape
+			}
+		}
+	}
+	return ""
+}
+
+// applyJSONShape replaces the type of an element with the generic shape from a "jsonshape" tag.
+// - "string" is a string, "object" is an object with no known fields and "array" is a list of any values.
+func (r *Reflector) applyJSONShape(currentElem *types.TypeElement, shape string) {
+	var genericType generictype.GenericType
+	switch shape {
+	case "string":
+		genericType = generictype.String
+	case "object":
+		genericType = generictype.Struct
+	case "array":
+		genericType = generictype.List
+	default:
+		currentElem.Error = JSONShapeErr
+		return
+	}
+
+	currentElem.Type = genericType.String()
+	currentElem.TypeCategory = genericType.Category().String()
+	currentElem.NativeDefault().Options.AddKeyVal("JSONShape", shape)
+
+	if genericType == generictype.List {
+		// List items are untyped so they allow any value.
+		itemElem := currentElem.NewChild("")
+		itemElem.Type = generictype.Interface.String()
+		itemElem.TypeCategory = generictype.Interface.Category().String()
+		itemElem.Nullable = true
+	}
+}
+
+// stringMarshalerOf returns the name of the marshaler interface of a type and true if the type is reflected as a string.
+// - Only used with RespectMarshalers. Known types (e.g. time.Time) and references are not affected.
+func (r *Reflector) stringMarshalerOf(t reflect.Type, genericType generictype.GenericType) (string, bool) {
+	if !r.RespectMarshalers || genericType.Category() == typecategory.Known || genericType.Category() == typecategory.Reference {
+		return "", false
+	}
+
+	if implements(t, jsonMarshalerType) {
+		return "json.Marshaler", true
+	}
+	if implements(t, textMarshalerType) {
+		return "encoding.TextMarshaler", true
+	}
+	return "", false
+}
+
+// enumValuesOf returns the enum values of a type registered with RegisterEnum or that implements EnumValues.
+func (r *Reflector) enumValuesOf(t reflect.Type) ([]string, bool) {
+	if values, ok := r.enums[t.Name()]; ok && t.Name() != "" {
+		return values, true
+	}
+	if t.Implements(enumValuesType) {
+		return reflect.Zero(t).Interface().(EnumValues).EnumValues(), true
+	}
+	if reflect.PtrTo(t).Implements(enumValuesType) {
+		return reflect.New(t).Interface().(EnumValues).EnumValues(), true
+	}
+	return nil, false
+}
+
+// protobufFieldNumber returns the field number from a protobuf struct tag or "" if there is none.
+// - The field number is the first numeric value in the comma-separated tag.
+func protobufFieldNumber(tag string) string {
+	for _, part := range strings.Split(tag, ",") {
+		if _, err := strconv.Atoi(part); err == nil {
+			return part
+		}
+	}
+	return ""
+}
+
+// tagConstraints returns the constraints from a b9schema struct tag, e.g. `b9schema:"minimum=0,pattern=^[a-z]+$"`.
+// - A pattern continues up to the next known option so it can contain commas, e.g. "pattern=^[a-z]{1,3}$".
+// - A constraint without a key, e.g. "=5", is returned under the key "" so it can be reported as an error.
+func tagConstraints(tag string) map[string]string {
+	out := map[string]string{}
+	if tag == "" {
+		return out
+	}
+
+	key := ""
+	for _, part := range strings.Split(tag, ",") {
+		if strings.HasPrefix(part, "description=") {
+			// The description is the rest of the tag.
+			break
+		}
+
+		partKey := ""
+		for _, constraintKey := range types.ConstraintKeys {
+			if strings.HasPrefix(part, constraintKey+"=") {
+				partKey = constraintKey
+				break
+			}
+		}
+
+		switch {
+		case partKey != "":
+			key = partKey
+			out[key] = strings.TrimPrefix(part, key+"=")
+		case key == "pattern":
+			out[key] += "," + part
+		case strings.HasPrefix(part, "="):
+			key = ""
+			out[key] = strings.TrimPrefix(part, "=")
+		default:
+			key = ""
+		}
+	}
+	return out
+}
+
+// tagDescription returns the description from a b9schema struct tag, e.g. `b9schema:"description=Name of the user"`.
+// - The description is the last option of the tag so it can contain commas.
+func tagDescription(tag string) string {
+	parts := strings.Split(tag, ",")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "description=") {
+			return strings.TrimPrefix(strings.Join(parts[i:], ","), "description=")
+		}
+	}
+	return ""
+}
+
+// mapKeyName returns the name of a map key. Keys that are not strings are formatted with fmt, e.g. 1 is "1".
+func mapKeyName(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	return fmt.Sprint(k.Interface())
+}
+
+// hasTagOption returns true if a struct field tag has an option, e.g. "omitempty" in `json:"name,omitempty"`.
+func hasTagOption(s *reflect.StructField, tagName, option string) bool {
+	if s == nil {
+		return false
+	}
+
+	parts := strings.Split(s.Tag.Get(tagName), ",")
+	for _, part := range parts[1:] {
+		if part == option {
+			return true
+		}
+	}
+	return false
+}
+
+// implements returns true if a type or a pointer to the type implements an interface.
+func implements(t reflect.Type, intf reflect.Type) bool {
+	return t.Implements(intf) || reflect.PtrTo(t).Implements(intf)
+}
+
+// embeddedStruct returns the struct value of a field whose fields are promoted to its parent, its ancestor TypeRef key and true.
+// - Embedded structs and pointers to structs without a json name are promoted, like encoding/json does.
+// - Struct fields with the json "inline" option are promoted too.
+// - Types with their own JSON form (known types, marshalers, shapes) and cyclical references are not promoted.
+func (r *Reflector) embeddedStruct(ancestorTypeRef types.AncestorTypeRef, structField reflect.StructField, v reflect.Value) (reflect.Value, string, bool) {
+	jsonTag := structField.Tag.Get("json")
+	if jsonTag == "-" {
+		return v, "", false
+	}
+
+	tagParts := strings.Split(jsonTag, ",")
+	inline := false
+	for _, option := range tagParts[1:] {
+		if option == "inline" {
+			inline = true
+		}
+	}
+	if !(structField.Anonymous && tagParts[0] == "") && !(inline && structField.PkgPath == "") {
+		return v, "", false
+	}
+
+	t := structField.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsNil() {
+			v = reflect.New(t).Elem()
+		} else {
+			v = v.Elem()
+		}
+	}
+
+	if t.Kind() != reflect.Struct || generictype.GenericTypeOf(v) != generictype.Struct {
+		return v, "", false
+	}
+	if _, ok := knownTypeOf(t); ok {
+		return v, "", false
+	}
+	if _, ok := r.knownStringFormat(t); ok {
+		return v, "", false
+	}
+	if _, ok := sqlNullTypes[t]; ok && r.SQLNullTypes {
+		return v, "", false
+	}
+	if implements(t, jsonMarshalerType) || implements(t, textMarshalerType) || jsonShapeOf(t) != "" {
+		return v, "", false
+	}
+
+	// Keys match the TypeRef names that reflectTypeImpl adds to ancestors.
+	ancestorKey := ""
+	if t.Name() != "" {
+		if r.OnlyExportedRefs && !token.IsExported(t.Name()) {
+			ancestorKey = t.PkgPath() + "." + t.Name()
+		} else if name, ok := r.typeRefNames[t.PkgPath()+"."+t.Name()]; ok && r.QualifyTypeRefs {
+			ancestorKey = name
+		} else {
+			ancestorKey = genericTypeName(t.Name())
+		}
+	}
+	if ancestorTypeRef.Contains(ancestorKey) {
+		return v, "", false
+	}
+
+	return v, ancestorKey, true
+}
+
+// hasField returns true if a struct element has a child with the Go name or json name of another element.
+func hasField(currentElem *types.TypeElement, fieldElem *types.TypeElement) bool {
+	jsonName := fieldElem.GetNativeType("json").Name
+	for _, childElem := range currentElem.Children {
+		if childElem.Name == fieldElem.Name || childElem.GetNativeType("json").Name == jsonName {
+			return true
+		}
+	}
+	return false
+}
+
+// setDefault records the "default" struct tag of a field.
+// - Set after reflection so the default is not copied to the field's TypeRef.
+// - If the field is an enum, the default must be one of the enum values.
+func (r *Reflector) setDefault(currentElem *types.TypeElement, structField *reflect.StructField) {
+	defaultVal, ok := structField.Tag.Lookup("default")
+	if !ok {
+		return
+	}
+
+	currentElem.NativeDefault().Options.AddKeyVal("Default", defaultVal)
+
+	if enumValues := currentElem.EnumValues(); enumValues != nil && currentElem.Error == "" {
+		for _, enumVal := range enumValues {
+			if enumVal == defaultVal {
+				return
+			}
+		}
+
+		currentElem.Error = types.DefaultEnumErr
+		currentElem.NativeDefault().Error = fmt.Sprintf("default %q is not one of %q", defaultVal, enumValues)
+
+		if r.Strictness == Strict {
+			r.checkStrict(currentElem)
+		}
+	}
+}
+
+// setConstraints records validation constraints from the "b9schema" struct tag, e.g. `b9schema:"minimum=0,maximum=100"`.
+// - Numeric constraints apply to integers and floats. String constraints apply to strings.
+// - Constraints that do not apply to the type or have invalid values are not recorded and set an error.
+func (r *Reflector) setConstraints(currentElem *types.TypeElement, structField *reflect.StructField) {
+	constraints := tagConstraints(structField.Tag.Get("b9schema"))
+
+	// Constraints without a key are checked first so they are not masked by other errors.
+	for _, key := range append([]string{""}, types.ConstraintKeys...) {
+		val, ok := constraints[key]
+		if !ok {
+			continue
+		}
+
+		if errText := constraintErr(currentElem, key, val); errText != "" {
+			if currentElem.Error == "" {
+				currentElem.Error = errText
+				currentElem.NativeDefault().Error = fmt.Sprintf("%s=%s is not valid for %s", key, val, currentElem.Type)
+
+				if r.Strictness == Strict {
+					r.checkStrict(currentElem)
+				}
+			}
+			continue
+		}
+
+		currentElem.SetConstraint(key, val)
+	}
+}
+
+// constraintErr returns the error for a constraint or "" if the constraint is valid for the element.
+func constraintErr(currentElem *types.TypeElement, key, val string) string {
+	switch key {
+	case "":
+		return types.ConstraintValueErr
+	case "minimum", "maximum":
+		if currentElem.Type != generictype.Integer.String() && currentElem.Type != generictype.Float.String() {
+			return types.ConstraintTypeErr
+		}
+		if _, err := strconv.ParseFloat(val, 64); err != nil {
+			return types.ConstraintValueErr
+		}
+	case "minLength", "maxLength", "pattern":
+		if currentElem.Type != generictype.String.String() {
+			return types.ConstraintTypeErr
+		}
+		if key == "pattern" {
+			if _, err := regexp.Compile(val); err != nil {
+				return types.ConstraintValueErr
+			}
+		} else if n, err := strconv.Atoi(val); err != nil || n < 0 {
+			return types.ConstraintValueErr
+		}
+	}
+	return ""
+}
+
+// typeRefName returns a unique TypeRef name for a named type.
+// - Names are assigned in the order types are found and are stable for the life of the Schema.
+// - Clashing names are prefixed with package path segments from the end of the path, e.g. "models.User" is "ModelsUser".
+func (r *Reflector) typeRefName(t reflect.Type) string {
+	key := t.PkgPath() + "." + t.Name()
+	if name, ok := r.typeRefNames[key]; ok {
+		return name
+	}
+
+	segments := strings.Split(t.PkgPath(), "/")
+
+	name := genericTypeName(t.Name())
+	for i := len(segments) - 1; ; i-- {
+		if owner, ok := r.typeRefOwners[name]; !ok || owner == key {
+			break
+		}
+
+		if i >= 0 {
+			name = exportedName(segments[i]) + name
+		} else {
+			// Package path is exhausted. This only happens for types with the same name and package path.
+			name = fmt.Sprintf("%s%d", genericTypeName(t.Name()), len(r.typeRefNames))
+		}
+	}
+
+	r.typeRefNames[key] = name
+	r.typeRefOwners[name] = key
+
+	return name
+}
+
+// genericTypeName converts the name of an instantiated generic type to an identifier, e.g. "Box[string]" is "BoxString".
+// - Package paths of type arguments are removed, e.g. "Box[example.com/pkg.User]" is "BoxUser".
+// - Pointers and slices are spelled out, e.g. "Box[*int]" is "BoxPtrInt" and "Box[[]string]" is "BoxSliceString".
+// - Names without type arguments are returned unchanged.
+func genericTypeName(name string) string {
+	if !strings.Contains(name, "[") {
+		return name
+	}
+
+	var out, word strings.Builder
+	flush := func() {
+		out.WriteString(util.Capitalize(word.String()))
+		word.Reset()
+	}
+
+	for i, c := range name {
+		switch {
+		case c == '.' || c == '/':
+			// Package path segments and qualifiers are dropped.
+			word.Reset()
+		case c == '*':
+			flush()
+			out.WriteString("Ptr")
+		case c == '[' && strings.HasPrefix(name[i:], "[]"):
+			flush()
+			out.WriteString("Slice")
+		case c == '[' || c == ']' || c == ',' || c == ' ':
+			flush()
+		default:
+			// Package paths may have other characters, e.g. "my-pkg". They are dropped with their segment.
+			word.WriteRune(c)
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+// exportedName converts a package path segment to a capitalized identifier, e.g. "go-yaml.v2" is "GoYamlV2".
+func exportedName(segment string) string {
+	parts := strings.FieldsFunc(segment, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+
+	return strings.Join(parts, "")
+}
+
 // addTypeRef adds a TypeRef for the current element.
 // - This function should only be called on an element with a TypeRef.
 func (r *Reflector) addTypeRef(currentElem *types.TypeElement) {
@@ -186,13 +1074,21 @@ func (r *Reflector) addTypeRef(currentElem *types.TypeElement) {
 		return
 	}
 
-	// Skip if the TypeRef has already been captured.
-	if r.Schema.TypeRefs.ChildByName(currentElem.NativeDefault().TypeRef, nil) != nil {
+	// Skip if the TypeRef has a cyclical reference error.
+	if currentElem.Error == types.CyclicalReferenceErr {
 		return
 	}
 
-	// Skip if the TypeRef has a cyclical reference error.
-	if currentElem.Error == types.CyclicalReferenceErr {
+	// Skip if the TypeRef has already been captured.
+	// - A type from another package with the same name and a different shape is an error. Use QualifyTypeRefs to keep both.
+	if existingElem := r.Schema.TypeRefs.ChildByName(currentElem.NativeDefault().TypeRef, nil); existingElem != nil {
+		if currentElem.Error == "" && !sameTypeRef(existingElem, currentElem) {
+			currentElem.Error = types.TypeRefConflictErr
+			currentElem.NativeDefault().Error = fmt.Sprintf("type ref %q is used by %s.%s and %s.%s",
+				currentElem.NativeDefault().TypeRef,
+				nativeOption(existingElem, "Type.PkgPath"), nativeOption(existingElem, "Type.Name"),
+				nativeOption(currentElem, "Type.PkgPath"), nativeOption(currentElem, "Type.Name"))
+		}
 		return
 	}
 
@@ -203,11 +1099,51 @@ func (r *Reflector) addTypeRef(currentElem *types.TypeElement) {
 	refElem.TypeRef = ""
 	refElem.NativeDefault().TypeRef = ""
 
+	// Tag dialects and descriptions describe the field that was copied, not the type. The json name of a type is its TypeRef name.
+	refElem.Description = ""
+	for dialect := range refElem.Native {
+		if dialect != refElem.NativeDialect {
+			delete(refElem.Native, dialect)
+		}
+	}
+	jsonNative := types.NewNativeType("json")
+	jsonNative.Name = refElem.Name
+	refElem.Native["json"] = jsonNative
+
 	r.typeRefRecursion(refElem)
 
 	r.Schema.TypeRefs.AddChild(refElem)
 }
 
+// sameTypeRef returns true if two elements with the same TypeRef name can share a TypeRef.
+// - Elements with the same package and Go type name are the same Go type. Instantiations of a generic type have different names.
+// - Other elements must have the same type and the same fields.
+func sameTypeRef(a, b *types.TypeElement) bool {
+	if nativeOption(a, "Type.PkgPath") == nativeOption(b, "Type.PkgPath") && nativeOption(a, "Type.Name") == nativeOption(b, "Type.Name") {
+		return true
+	}
+
+	if a.Type != b.Type || len(a.Children) != len(b.Children) {
+		return false
+	}
+
+	bChildren := b.ChildMap()
+	for _, aChild := range a.Children {
+		bChild, ok := bChildren[aChild.Name]
+		if !ok || aChild.Type != bChild.Type {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nativeOption returns an option of the golang native type or "" if it is not set.
+func nativeOption(t *types.TypeElement, key string) string {
+	val, _ := t.NativeDefault().Options.Get(key)
+	return val
+}
+
 // typeRefRecursion is an internal recursive function to handle nested TypeRefs.
 // - Recursively process elements.
 // - If TypeRef is found, process TypeRef then remove its children.
@@ -230,12 +1166,52 @@ func (r *Reflector) typeRefRecursion(currentElem *types.TypeElement) {
 	}
 }
 
+// reflectGetterMethods adds read-only fields for the getter methods of a struct type.
+// - Methods with value and pointer receivers are included.
+func (r *Reflector) reflectGetterMethods(ancestorTypeRef types.AncestorTypeRef, currentElem *types.TypeElement, t reflect.Type) {
+	ptrType := reflect.PtrTo(t)
+
+	for i := 0; i < ptrType.NumMethod(); i++ {
+		method := ptrType.Method(i)
+
+		// The receiver is the only input.
+		if method.Type.NumIn() != 1 || method.Type.NumOut() != 1 || method.Name == "String" || method.Name == "Error" {
+			continue
+		}
+		if currentElem.ChildByName(method.Name, nil) != nil {
+			continue
+		}
+
+		targetValue := reflect.New(method.Type.Out(0)).Elem()
+		if category := generictype.GenericTypeOf(targetValue).Category(); category != typecategory.Basic && category != typecategory.Known {
+			continue
+		}
+
+		nextElem := currentElem.NewChild(method.Name)
+		nextElem.NativeDefault().Options.AddKeyVal("Access", types.ReadOnlyAccess)
+		nextElem.NativeDefault().Options.AddBool("Getter", true)
+
+		r.reflectTypeImpl(ancestorTypeRef.Copy(), nextElem, targetValue, nil)
+	}
+}
+
 // reflectTypeInterfaceImpl refects on interface types
 // Interface is a special case which is either:
 // - nil -- nil has no discernable type and is an error
 // - a wrapper around another type -- ignore the interface and continue reflection with the wrapped type
 func (r *Reflector) reflectTypeInterfaceImpl(ancestorTypeRef types.AncestorTypeRef, currentElem *types.TypeElement, v reflect.Value, s *reflect.StructField) {
+	if impls := r.interfaceImpls[v.Type().Name()]; r.RecordInterfaceAsUnion && len(impls) > 0 {
+		r.reflectTypeUnionImpl(ancestorTypeRef, currentElem, impls)
+		return
+	}
+
 	if v.IsZero() {
+		// In Lenient mode, nil is an untyped element which allows any value.
+		if r.Strictness == Lenient {
+			currentElem.Nullable = true
+			return
+		}
+
 		// nil is an invalid element because its type cannot be determined
 		currentElem.Type = "invalid"
 		currentElem.Error = types.NilInterfaceErr
@@ -250,6 +1226,17 @@ func (r *Reflector) reflectTypeInterfaceImpl(ancestorTypeRef types.AncestorTypeR
 	r.reflectTypeImpl(ancestorTypeRef.Copy(), currentElem, v.Elem(), nil)
 }
 
+// reflectTypeUnionImpl reflects an interface as a union of its implementations.
+func (r *Reflector) reflectTypeUnionImpl(ancestorTypeRef types.AncestorTypeRef, currentElem *types.TypeElement, impls []interface{}) {
+	// Any implementation may be nil.
+	currentElem.Nullable = true
+	currentElem.NativeDefault().Options.AddKeyVal("Union", "oneOf")
+
+	for _, impl := range impls {
+		r.reflectTypeImpl(ancestorTypeRef.Copy(), currentElem.NewChild(""), reflect.ValueOf(impl), nil)
+	}
+}
+
 // reflectTypePointerImpl refects on pointer types
 func (r *Reflector) reflectTypePointerImpl(ancestorTypeRef types.AncestorTypeRef, currentElem *types.TypeElement, v reflect.Value, s *reflect.StructField) {
 	// Pointer is a memory address pointing to some other type element.
@@ -360,26 +1347,78 @@ func (r *Reflector) reflectTypeListImpl(ancestorTypeRef types.AncestorTypeRef, c
 	}
 }
 
+// reflectTypeMapValueImpl reflects on maps with a concrete value type.
+// - Map values are represented by a single unnamed child element, like list elements.
+// - If the map is empty, a new value of the map value type is used for typing.
+func (r *Reflector) reflectTypeMapValueImpl(ancestorTypeRef types.AncestorTypeRef, currentElem *types.TypeElement, v reflect.Value) {
+	var targetValue reflect.Value
+
+	if v.Len() == 0 {
+		targetValue = reflect.New(v.Type().Elem()).Elem()
+	} else {
+		// Use the value of the first key in sorted order for stable results.
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return mapKeyName(keys[i]) < mapKeyName(keys[j])
+		})
+		targetValue = v.MapIndex(keys[0])
+	}
+
+	nextElem := currentElem.NewChild("")
+	r.reflectTypeImpl(ancestorTypeRef.Copy(), nextElem, targetValue, nil)
+}
+
 // reflectTypeStructImpl reflects on struct types: Struct, Map
 // Struct and Map represent key-value pairs.
 // - Struct keys are field names which are always strings.
 // - Map keys can be any comprable Go type.
+// - Maps with interface values (e.g. decoded JSON) use their keys as fields.
 func (r *Reflector) reflectTypeStructImpl(ancestorTypeRef types.AncestorTypeRef, currentElem *types.TypeElement, v reflect.Value, s *reflect.StructField) {
 	switch v.Kind() {
 	case reflect.Struct:
 		if currentElem.Error == "" {
 			if v.NumField() == 0 {
-				currentElem.Error = types.EmptyStructErr
+				if !r.AllowEmptyStruct {
+					currentElem.Error = types.EmptyStructErr
+				}
+				return
+			}
+
+			// Reuse the fields of a type that was already reflected.
+			if entry := r.cachedFields(ancestorTypeRef, v.Type()); entry != nil {
+				for _, childElem := range entry.children {
+					currentElem.AddChild(childElem.Copy())
+				}
+				if entry.exportedFields == 0 {
+					currentElem.Error = types.NoExportedFieldsErr
+				}
 				return
 			}
 
 			// Count exported fields.
 			exportedFields := 0
 
+			// Fields promoted from embedded structs are added after the fields of the struct so outer fields win name collisions.
+			promoted := []*types.TypeElement{}
+
 			for i := 0; i < v.NumField(); i++ {
 				structField := v.Type().Field(i)
 				targetValue := v.Field(i)
 
+				// Flatten embedded structs like encoding/json does. Embedded types may be un-exported.
+				if embeddedValue, ancestorKey, ok := r.embeddedStruct(ancestorTypeRef, structField, targetValue); ok {
+					embeddedElem := currentElem.NewChild(structField.Name)
+					embeddedAncestors := ancestorTypeRef.Copy()
+					if ancestorKey != "" {
+						embeddedAncestors.Add(ancestorKey)
+					}
+					r.reflectTypeStructImpl(embeddedAncestors, embeddedElem, embeddedValue, &structField)
+					currentElem.RemoveChild(embeddedElem)
+
+					promoted = append(promoted, embeddedElem.Children...)
+					continue
+				}
+
 				// Skip un-exported fields.
 				if structField.PkgPath != "" {
 					continue
@@ -388,6 +1427,29 @@ func (r *Reflector) reflectTypeStructImpl(ancestorTypeRef types.AncestorTypeRef,
 
 				nextElem := currentElem.NewChild(structField.Name)
 
+				// Record declaration order since children are rendered alphabetically.
+				nextElem.NativeDefault().Options.AddKeyVal("FieldIndex", fmt.Sprintf("%d", i))
+
+				// Capture access mode from the "access" struct tag for request/response variants.
+				if access := structField.Tag.Get("access"); access != "" {
+					nextElem.NativeDefault().Options.AddKeyVal("Access", access)
+				}
+
+				// Capture the shape of a custom MarshalJSON from the "jsonshape" struct tag.
+				if shape := structField.Tag.Get("jsonshape"); shape != "" {
+					nextElem.NativeDefault().Options.AddKeyVal("JSONShape", shape)
+				}
+
+				// Capture field number from the "protobuf" struct tag, e.g. `protobuf:"bytes,1,opt,name=id,proto3"`.
+				if number := protobufFieldNumber(structField.Tag.Get("protobuf")); number != "" {
+					nextElem.NativeDefault().Options.AddKeyVal("ProtobufField", number)
+				}
+
+				// Capture the description from the "b9schema" struct tag.
+				if description := tagDescription(structField.Tag.Get("b9schema")); description != "" {
+					nextElem.Description = description
+				}
+
 				// Parse struct tags.
 				tags := types.ParseTags(structField.Tag)
 				if len(tags) > 0 {
@@ -401,9 +1463,39 @@ func (r *Reflector) reflectTypeStructImpl(ancestorTypeRef types.AncestorTypeRef,
 					}
 				}
 
+				// Renderers name fields with the json dialect so it is always set. Name defaults to the field name.
+				jsonNative := nextElem.Native["json"]
+				if jsonNative == nil {
+					jsonNative = types.NewNativeType("json")
+					nextElem.Native["json"] = jsonNative
+				}
+				if jsonNative.Name == "" {
+					jsonNative.Name = structField.Name
+				}
+
 				r.reflectTypeImpl(ancestorTypeRef.Copy(), nextElem, targetValue, &structField)
+				r.setDefault(nextElem, &structField)
+				r.setConstraints(nextElem, &structField)
+
+				// In Lenient mode, fields with errors are skipped.
+				if r.Strictness == Lenient && nextElem.Error != "" && nextElem.Error != types.CyclicalReferenceErr {
+					currentElem.RemoveChild(nextElem)
+				}
+			}
+
+			for _, promotedElem := range promoted {
+				if !hasField(currentElem, promotedElem) {
+					currentElem.AddChild(promotedElem)
+					exportedFields++
+				}
 			}
 
+			if r.IncludeGetterMethods {
+				r.reflectGetterMethods(ancestorTypeRef, currentElem, v.Type())
+			}
+
+			r.cacheFields(currentElem, v.Type(), exportedFields)
+
 			if exportedFields == 0 {
 				currentElem.Error = types.NoExportedFieldsErr
 				return
@@ -413,17 +1505,43 @@ func (r *Reflector) reflectTypeStructImpl(ancestorTypeRef types.AncestorTypeRef,
 	case reflect.Map:
 		currentElem.Native[currentElem.NativeDialect].Options.AddBool("IsNil", v.IsNil())
 
+		// Capture key pattern from the "keyPattern" struct tag for renderers that support it.
+		if s != nil {
+			if keyPattern := s.Tag.Get("keyPattern"); keyPattern != "" {
+				currentElem.NativeDefault().Options.AddKeyVal("KeyPattern", keyPattern)
+			}
+		}
+
+		// Capture allowed keys from the "keyEnum" struct tag, e.g. `keyEnum:"en,fr"`, or from a key type that implements EnumValues.
+		if s != nil && s.Tag.Get("keyEnum") != "" {
+			currentElem.SetKeyEnumValues(strings.Split(s.Tag.Get("keyEnum"), ","))
+		} else if keyValues, ok := r.enumValuesOf(v.Type().Key()); ok {
+			currentElem.SetKeyEnumValues(keyValues)
+		}
+
 		if currentElem.Error == "" {
-			// Map key must be ancestorTypeRef string.
+			// Map key must be ancestorTypeRef string. With AllowNonStringMapKeys, keys of other basic types are stringified.
 			if v.Type().Key().Kind() != reflect.String {
-				currentElem.Error = types.MapKeyTypeErr
-				currentElem.NativeDefault().Error = fmt.Sprintf("map key type must be string not %q", v.Type().Key())
+				keyType := generictype.GenericTypeOf(reflect.New(v.Type().Key()).Elem())
+				if !r.AllowNonStringMapKeys || keyType.Category() != typecategory.Basic {
+					currentElem.Error = types.MapKeyTypeErr
+					currentElem.NativeDefault().Error = fmt.Sprintf("map key type must be string not %q", v.Type().Key())
+					return
+				}
+				currentElem.NativeDefault().Options.AddKeyVal("MapKeyType", keyType.String())
+			}
+
+			// Maps with a concrete value type are typed by their values, not their keys.
+			if v.Type().Elem().Kind() != reflect.Interface {
+				r.reflectTypeMapValueImpl(ancestorTypeRef, currentElem, v)
 				return
 			}
 
-			// Empty map not allowed.
+			// Empty map not allowed unless it is a catch-all (json "inline", mapstructure "remain") for other properties of its parent.
 			if v.Len() == 0 {
-				currentElem.Error = types.EmptyMapErr
+				if !hasTagOption(s, "json", "inline") && !hasTagOption(s, "mapstructure", "remain") {
+					currentElem.Error = types.EmptyMapErr
+				}
 				return
 			}
 
@@ -439,7 +1557,7 @@ func (r *Reflector) reflectTypeStructImpl(ancestorTypeRef types.AncestorTypeRef,
 			keys := []*mapKey{}
 			for _, k := range v.MapKeys() {
 				newKey := &mapKey{
-					Name:  k.Interface().(string),
+					Name:  mapKeyName(k),
 					Value: k,
 				}
 				newKey.ExportName = util.Capitalize(newKey.Name)
@@ -0,0 +1,1825 @@
+package reflector
+
+import (
+	"archive/tar"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/typecategory"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"github.com/gitmann/b9schema-reflector-golang/renderer"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type contextTestStruct struct {
+	Name  string
+	Child struct {
+		Value int
+	}
+}
+
+func TestReflector_DeriveSchemaWithContext(t *testing.T) {
+	r := NewReflector()
+
+	schema, err := r.DeriveSchemaWithContext(context.Background(), contextTestStruct{})
+	if err != nil {
+		t.Errorf("TEST_FAIL background context: err=%s", err)
+	} else if schema == nil || len(schema.Root.Children) != 1 {
+		t.Errorf("TEST_FAIL background context: schema not derived")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r.Reset()
+	schema, err = r.DeriveSchemaWithContext(ctx, contextTestStruct{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("TEST_FAIL cancelled context: got err=%v, want %v", err, context.Canceled)
+	}
+	if schema != nil {
+		t.Errorf("TEST_FAIL cancelled context: got schema, want nil")
+	}
+}
+
+type keyPatternTestStruct struct {
+	Labels map[string]string `keyPattern:"^[a-z]{2,4}$"`
+}
+
+func TestReflector_KeyPattern(t *testing.T) {
+	r := NewReflector()
+	schema := r.DeriveSchema(keyPatternTestStruct{Labels: map[string]string{"ab": "x"}})
+
+	labels := schema.TypeRefs.ChildByName("keyPatternTestStruct", nil).ChildByName("Labels", nil)
+	if labels == nil {
+		t.Fatalf("TEST_FAIL Labels element not found")
+	}
+
+	got, _ := labels.NativeDefault().Options.Get("KeyPattern")
+	if want := "^[a-z]{2,4}$"; got != want {
+		t.Errorf("TEST_FAIL KeyPattern: got %q, want %q", got, want)
+	}
+}
+
+// qualifyTestStruct has two types named "Error" from different packages.
+type qualifyTestStruct struct {
+	URLError  url.Error
+	ExecError exec.Error
+}
+
+func TestReflector_QualifyTypeRefs(t *testing.T) {
+	tests := []struct {
+		qualify   bool
+		wantNames []string
+	}{
+		// Same-named types share a single TypeRef.
+		{qualify: false, wantNames: []string{"qualifyTestStruct", "Error", "error"}},
+		// The first type keeps its name. The clashing type is prefixed with its package name.
+		{qualify: true, wantNames: []string{"qualifyTestStruct", "Error", "ExecError", "error"}},
+	}
+
+	for _, test := range tests {
+		r := NewReflector()
+		r.QualifyTypeRefs = test.qualify
+		schema := r.DeriveSchema(qualifyTestStruct{})
+
+		gotNames := map[string]bool{}
+		for _, refElem := range schema.TypeRefs.Children {
+			gotNames[refElem.Name] = true
+		}
+
+		if len(gotNames) != len(test.wantNames) {
+			t.Errorf("TEST_FAIL qualify=%t: got %d TypeRefs, want %d", test.qualify, len(gotNames), len(test.wantNames))
+		}
+		for _, name := range test.wantNames {
+			if !gotNames[name] {
+				t.Errorf("TEST_FAIL qualify=%t: TypeRef %q not found", test.qualify, name)
+			}
+		}
+	}
+}
+
+// conflictTestStruct has a map and a struct named "Header" from different packages.
+type conflictTestStruct struct {
+	HTTPHeader http.Header
+	TarHeader  tar.Header
+}
+
+func TestReflector_TypeRefConflict(t *testing.T) {
+	tests := []struct {
+		qualify   bool
+		wantError string
+	}{
+		// The second type gets an error instead of silently sharing the first TypeRef.
+		{qualify: false, wantError: types.TypeRefConflictErr},
+		// Qualified names keep both types.
+		{qualify: true, wantError: ""},
+	}
+
+	for _, test := range tests {
+		r := NewReflector()
+		r.QualifyTypeRefs = test.qualify
+		schema := r.DeriveSchema(conflictTestStruct{})
+
+		rootElem := schema.Root.Children[0]
+		if got := rootElem.ChildByName("HTTPHeader", nil).Error; got != "" {
+			t.Errorf("TEST_FAIL qualify=%t: HTTPHeader: got error %q, want none", test.qualify, got)
+		}
+		if got := rootElem.ChildByName("TarHeader", nil).Error; got != test.wantError {
+			t.Errorf("TEST_FAIL qualify=%t: TarHeader: got error %q, want %q", test.qualify, got, test.wantError)
+		}
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"models":     "Models",
+		"go-yaml.v2": "GoYamlV2",
+		"github.com": "GithubCom",
+	}
+
+	for segment, want := range tests {
+		if got := exportedName(segment); got != want {
+			t.Errorf("TEST_FAIL %q: got %q, want %q", segment, got, want)
+		}
+	}
+}
+
+type rpcRequest struct {
+	Query string
+}
+
+type rpcResponse struct {
+	Results []string
+}
+
+func TestReflector_DeriveFuncSchema(t *testing.T) {
+	handler := func(ctx context.Context, req rpcRequest) (*rpcResponse, error) {
+		return nil, nil
+	}
+
+	r := NewReflector()
+	req, resp, err := r.DeriveFuncSchema(handler)
+	if err != nil {
+		t.Fatalf("TEST_FAIL handler: err=%s", err)
+	}
+	if req == nil || req.TypeRefs.ChildByName("rpcRequest", nil) == nil {
+		t.Errorf("TEST_FAIL handler: rpcRequest not found in request schema")
+	}
+	if resp == nil || resp.TypeRefs.ChildByName("rpcResponse", nil) == nil {
+		t.Errorf("TEST_FAIL handler: rpcResponse not found in response schema")
+	}
+	if req != nil && req.TypeRefs.ChildByName("rpcResponse", nil) != nil {
+		t.Errorf("TEST_FAIL handler: request schema has response types")
+	}
+
+	// Functions without a request or response have nil schemas.
+	req, resp, err = r.DeriveFuncSchema(func() error { return nil })
+	if err != nil || req != nil || resp != nil {
+		t.Errorf("TEST_FAIL no params: got req=%v resp=%v err=%v, want nil", req, resp, err)
+	}
+
+	if _, _, err := r.DeriveFuncSchema(rpcRequest{}); err == nil {
+		t.Errorf("TEST_FAIL not a function: got nil error")
+	}
+}
+
+type cacheLeaf struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+type cacheNode struct {
+	A, B, C, D cacheLeaf
+	Leaves     []cacheLeaf
+	ByName     map[string]cacheLeaf
+}
+
+type cacheRoot struct {
+	A, B, C, D, E, F, G, H cacheNode
+}
+
+func TestReflector_CacheTypes(t *testing.T) {
+	r := NewReflector()
+	r.CacheTypes = true
+	cached := r.DeriveSchema(cacheRoot{})
+
+	uncached := NewReflector().DeriveSchema(cacheRoot{})
+	if cached.Hash() != uncached.Hash() {
+		t.Errorf("TEST_FAIL cache types: hash differs")
+	}
+
+	// Reset clears the cache.
+	r.Reset()
+	if cached = r.DeriveSchema(cacheRoot{}); cached.Hash() != uncached.Hash() {
+		t.Errorf("TEST_FAIL cache types: hash differs after reset")
+	}
+}
+
+func BenchmarkReflector_CacheTypes(b *testing.B) {
+	for _, cacheTypes := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cache=%t", cacheTypes), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				r := NewReflector()
+				r.CacheTypes = cacheTypes
+				r.DeriveSchema(cacheRoot{})
+			}
+		})
+	}
+}
+
+// nestedType returns a struct type with levels of nested "Next" fields around a string.
+func nestedType(levels int) reflect.Type {
+	t := reflect.TypeOf("")
+	for i := 0; i < levels; i++ {
+		t = reflect.StructOf([]reflect.StructField{{Name: "Next", Type: t}})
+	}
+	return t
+}
+
+func TestReflector_MaxDepth(t *testing.T) {
+	tests := []struct {
+		maxDepth  int
+		wantDepth int
+		wantError string
+	}{
+		// Unlimited depth reaches the string inside the 50 structs.
+		{maxDepth: 0, wantDepth: 51, wantError: ""},
+		// The first element deeper than MaxDepth has an error and no children.
+		{maxDepth: 10, wantDepth: 11, wantError: MaxDepthErr},
+	}
+
+	for _, test := range tests {
+		schema := NewReflector(WithMaxDepth(test.maxDepth)).DeriveSchemaFromType(nestedType(50))
+
+		depth := 0
+		elem := schema.Root
+		for len(elem.Children) > 0 {
+			elem = elem.Children[0]
+			depth++
+		}
+
+		if depth != test.wantDepth {
+			t.Errorf("TEST_FAIL max depth=%d: got depth %d, want %d", test.maxDepth, depth, test.wantDepth)
+		}
+		if elem.Error != test.wantError {
+			t.Errorf("TEST_FAIL max depth=%d: got error %q, want %q", test.maxDepth, elem.Error, test.wantError)
+		}
+	}
+}
+
+// registeredDecimal is a known type once it is registered with RegisterKnown.
+type registeredDecimal struct {
+	value int64
+}
+
+type registeredStruct struct {
+	Amount registeredDecimal
+}
+
+func TestRegisterKnown(t *testing.T) {
+	decimalType := reflect.TypeOf(registeredDecimal{})
+
+	// Registration is safe while other goroutines look up known types.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterKnown(decimalType.PkgPath(), decimalType.Name(), "decimal", "")
+		}()
+		go func() {
+			defer wg.Done()
+			knownTypeOf(decimalType)
+		}()
+	}
+	wg.Wait()
+
+	schema := NewReflector().DeriveSchema(registeredStruct{})
+	amountElem := schema.TypeRefs.ChildByName("registeredStruct", nil).ChildByName("Amount", nil)
+	if amountElem.Type != "decimal" || amountElem.TypeCategory != typecategory.Known.String() {
+		t.Errorf("TEST_FAIL register known: got %s type %q, want known type %q", amountElem.TypeCategory, amountElem.Type, "decimal")
+	}
+	if amountElem.TypeRef != "" {
+		t.Errorf("TEST_FAIL register known: got TypeRef %q, want none", amountElem.TypeRef)
+	}
+	if got, _ := amountElem.NativeDefault().Options.Get("Format"); got != "decimal" {
+		t.Errorf("TEST_FAIL register known: got format %q, want %q", got, "decimal")
+	}
+
+	// Conflicting registrations panic.
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TEST_FAIL register known: conflicting registration did not panic")
+		}
+	}()
+	RegisterKnown(decimalType.PkgPath(), decimalType.Name(), "money", "")
+}
+
+func TestReflector_MapValueKeyOrder(t *testing.T) {
+	// Non-string keys are sorted by name so the value of key 10 ("10" < "9") is used for typing.
+	r := NewReflector()
+	r.AllowNonStringMapKeys = true
+
+	for i := 0; i < 10; i++ {
+		r.Reset()
+		schema := r.DeriveSchema(map[int][]interface{}{9: {1}, 10: {"ten"}})
+
+		valueElem := schema.Root.Children[0].Children[0].Children[0]
+		if valueElem.Type != "string" {
+			t.Fatalf("TEST_FAIL map value key order: got %q, want %q", valueElem.Type, "string")
+		}
+	}
+}
+
+// IntegerTypes has one field for each integer type.
+type IntegerTypes struct {
+	Int     int
+	Int8    int8
+	Int16   int16
+	Int32   int32
+	Int64   int64
+	Uint    uint
+	Uint8   uint8
+	Uint16  uint16
+	Uint32  uint32
+	Uint64  uint64
+	Uintptr uintptr
+}
+
+// Test cyclical relationships:
+// A --> B --> C --> A
+type AStruct struct {
+	AName  string   `json:"aName,omitempty"`
+	AChild *BStruct `json:"aChild"`
+}
+
+type BStruct struct {
+	BName  string   `json:"bName"`
+	BChild *CStruct `json:"bChild"`
+}
+
+type CStruct struct {
+	CName  string   `json:"cName"`
+	CChild *AStruct `json:"cChild"`
+}
+
+type CycleTest struct {
+	Level  int      `json:"-"`
+	CycleA AStruct  `json:"cycleA"`
+	CycleB *BStruct `json:"cycleB"`
+	CycleC struct {
+		C CStruct `json:"c"`
+	}
+}
+
+// StringStruct has one string field.
+type StringStruct struct {
+	Value string
+}
+
+// Private Struct only has private fields.
+type PrivateStruct struct {
+	boolVal    bool
+	intVal     int
+	float64Val float64
+	stringVal  string
+}
+
+// BasicStruct has one field for each basic type.
+type BasicStruct struct {
+	BoolVal    bool
+	IntVal     int
+	Float64Val float64
+	StringVal  string
+}
+
+// define a struct for data storage
+type GoodEntity struct {
+	Message string
+	IntVal  int64
+	Same    bool
+
+	secret string
+}
+
+// StatusEnum is a named string type with a fixed set of values.
+type StatusEnum string
+
+func (s StatusEnum) EnumValues() []string {
+	return []string{"active", "inactive", "pending"}
+}
+
+func compareStrings(t *testing.T, testName string, gotStrings, wantStrings []string) {
+	// Split strings into lines.
+	gotLines := []string{}
+	for _, line := range gotStrings {
+		lines := strings.Split(line, "\n")
+		gotLines = append(gotLines, lines...)
+	}
+	wantLines := []string{}
+	for _, line := range wantStrings {
+		lines := strings.Split(line, "\n")
+		wantLines = append(wantLines, lines...)
+	}
+
+	if !reflect.DeepEqual(gotLines, wantLines) {
+		t.Errorf("TEST_FAIL %s", testName)
+
+		maxLen := len(gotLines)
+		if len(wantLines) > maxLen {
+			maxLen = len(wantLines)
+		}
+
+		type diffStruct struct {
+			got, want string
+		}
+		diff := []*diffStruct{}
+
+		for i := 0; i < maxLen; i++ {
+			newDiff := &diffStruct{}
+
+			if i < len(gotLines) {
+				newDiff.got = gotLines[i]
+			}
+
+			if i < len(wantLines) {
+				newDiff.want = wantLines[i]
+			}
+
+			diff = append(diff, newDiff)
+		}
+
+		// Dump got and want lines.
+		outLines := []string{}
+
+		outLines = append(outLines, "***** GOT:")
+		for i, newDiff := range diff {
+			flag := " "
+			if newDiff.got != newDiff.want {
+				flag = ">"
+			}
+
+			outLines = append(outLines, fmt.Sprintf("%05d%s| %s", i, flag, newDiff.got))
+		}
+
+		outLines = append(outLines, "***** WANT:")
+		for i, newDiff := range diff {
+			flag := " "
+			if newDiff.got != newDiff.want {
+				flag = ">"
+			}
+
+			outLines = append(outLines, fmt.Sprintf("%05d%s| %s", i, flag, newDiff.want))
+		}
+
+		t.Errorf("TEST_FAIL %s\n%s", testName, strings.Join(outLines, "\n"))
+	} else {
+		t.Logf("TEST_OK %s", testName)
+	}
+}
+
+// EmptyStructStruct has an empty struct marker field.
+type EmptyStructStruct struct {
+	Ack  struct{} `json:"ack"`
+	Name string   `json:"name"`
+}
+
+func TestReflector_AllowEmptyStruct(t *testing.T) {
+	r := NewReflector()
+
+	// Default is an error.
+	schema := r.DeriveSchema(EmptyStructStruct{})
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "allow-empty-struct: default", gotStrings, []string{
+		`TypeRefs.EmptyStructStruct:{}`,
+		`TypeRefs.EmptyStructStruct:{}.!Ack:{}! ERROR:empty struct not supported`,
+		`TypeRefs.EmptyStructStruct:{}.Name:string`,
+		`Root.{}:EmptyStructStruct`,
+	})
+
+	// Empty struct is an empty object.
+	r.AllowEmptyStruct = true
+	schema = r.Reset().DeriveSchema(EmptyStructStruct{})
+
+	gotStrings, err := renderer.NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL allow-empty-struct: err=%s", err)
+	}
+	compareStrings(t, "allow-empty-struct: dialect=openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    EmptyStructStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        ack:`,
+		`          type: object`,
+		`        name:`,
+		`          type: string`,
+		`      required:`,
+		`        - ack`,
+		`        - name`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/EmptyStructStruct'`,
+	})
+}
+
+// StrictnessStruct has a nil interface and an invalid field.
+type StrictnessStruct struct {
+	Any   interface{}
+	Chan  chan int
+	Name  string
+	Value int
+}
+
+func TestReflector_Strictness(t *testing.T) {
+	tests := []struct {
+		strictness  Strictness
+		wantErr     bool
+		wantStrings []string
+	}{
+		{
+			strictness: Normal,
+			wantStrings: []string{
+				`Root.{}`,
+				`Root.{}.!Any:invalid! ERROR:interface element is nil`,
+				`Root.{}.!Chan:invalid:chan! ERROR:kind not supported`,
+				`Root.{}.Name:string`,
+				`Root.{}.Value:integer`,
+			},
+		},
+		{
+			strictness: Lenient,
+			wantStrings: []string{
+				`Root.{}`,
+				`Root.{}.Any:interface`,
+				`Root.{}.Name:string`,
+				`Root.{}.Value:integer`,
+			},
+		},
+		{
+			strictness: Strict,
+			wantErr:    true,
+		},
+	}
+
+	for _, test := range tests {
+		r := NewReflector()
+		r.Strictness = test.strictness
+
+		schema, err := r.DeriveSchemaWithContext(context.Background(), StrictnessStruct{})
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("TEST_FAIL strictness=%s: got nil error", test.strictness)
+			} else if want := "Root.{}.Any ERROR:interface element is nil"; err.Error() != want {
+				t.Errorf("TEST_FAIL strictness=%s: got err=%q, want %q", test.strictness, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("TEST_FAIL strictness=%s: err=%s", test.strictness, err)
+			continue
+		}
+
+		opt := renderer.NewOptions()
+		opt.DeReference = true
+		gotStrings, _ := renderer.NewSimpleRenderer(opt).ProcessResult(schema)
+		compareStrings(t, "strictness="+test.strictness.String(), gotStrings, test.wantStrings)
+	}
+}
+
+// BinaryKey is serialized with MarshalBinary.
+type BinaryKey struct {
+	Data []byte
+}
+
+func (k BinaryKey) MarshalBinary() ([]byte, error) {
+	return k.Data, nil
+}
+
+// BinaryStruct has binary marshaler fields.
+type BinaryStruct struct {
+	Key    BinaryKey  `json:"key"`
+	KeyPtr *BinaryKey `json:"keyPtr"`
+}
+
+func TestReflector_BinaryMarshalerAsBytes(t *testing.T) {
+	opt := renderer.NewOptions()
+	opt.DeReference = true
+
+	// Default is to reflect the Go type.
+	r := NewReflector()
+	gotStrings, _ := renderer.NewSimpleRenderer(opt).ProcessResult(r.DeriveSchema(BinaryStruct{}))
+	compareStrings(t, "binary-marshaler: default", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.Key:{}`,
+		`Root.{}.Key:{}.Data:string`,
+		`Root.{}.KeyPtr:{}`,
+		`Root.{}.KeyPtr:{}.Data:string`,
+	})
+
+	r = NewReflector()
+	r.BinaryMarshalerAsBytes = true
+	schema := r.DeriveSchema(BinaryStruct{})
+
+	keyPtr := schema.Root.Children[0].ChildByName("KeyPtr", nil)
+	if !keyPtr.Nullable {
+		t.Errorf("TEST_FAIL binary-marshaler: pointer is not nullable")
+	}
+
+	gotStrings, _ = renderer.NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "binary-marshaler: bytes", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  key:`,
+		`                    type: string`,
+		`                    format: byte`,
+		`                  keyPtr:`,
+		`                    type: string`,
+		`                    format: byte`,
+		`                required:`,
+		`                  - key`,
+	})
+}
+
+func TestReflector_RootName(t *testing.T) {
+	r := NewReflector()
+	r.RootName = "getEntity"
+	schema := r.DeriveSchema(GoodEntity{})
+
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "root-name: dialect=simple", gotStrings, []string{
+		`TypeRefs.GoodEntity:{}`,
+		`TypeRefs.GoodEntity:{}.IntVal:integer`,
+		`TypeRefs.GoodEntity:{}.Message:string`,
+		`TypeRefs.GoodEntity:{}.Same:boolean`,
+		`Root.getEntity:{}:GoodEntity`,
+	})
+
+	gotStrings, _ = renderer.NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	compareStrings(t, "root-name: dialect=openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    GoodEntity:`,
+		`      type: object`,
+		`      properties:`,
+		`        IntVal:`,
+		`          type: integer`,
+		`          format: int64`,
+		`        Message:`,
+		`          type: string`,
+		`        Same:`,
+		`          type: boolean`,
+		`      required:`,
+		`        - IntVal`,
+		`        - Message`,
+		`        - Same`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      operationId: getEntity`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/GoodEntity'`,
+	})
+}
+
+// URLStruct has URL fields.
+type URLStruct struct {
+	Home url.URL  `json:"home"`
+	Link *url.URL `json:"link"`
+}
+
+func TestReflector_URL(t *testing.T) {
+	r := NewReflector()
+	schema := r.DeriveSchema(URLStruct{})
+
+	// URLs are known types so they are not TypeRefs.
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "url: dialect=simple", gotStrings, []string{
+		`TypeRefs.URLStruct:{}`,
+		`TypeRefs.URLStruct:{}.Home:string`,
+		`TypeRefs.URLStruct:{}.Link:string`,
+		`Root.{}:URLStruct`,
+	})
+
+	render := renderer.NewOpenAPIRenderer("/test/path", nil)
+	render.NullableKeyword = renderer.NullableOpenAPI3
+
+	gotStrings, _ = render.ProcessResult(schema)
+	compareStrings(t, "url: dialect=openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    URLStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        home:`,
+		`          type: string`,
+		`          format: uri`,
+		`        link:`,
+		`          nullable: true`,
+		`          type: string`,
+		`          format: uri`,
+		`      required:`,
+		`        - home`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/URLStruct'`,
+	})
+}
+
+func TestReflector_Unsigned(t *testing.T) {
+	schema := NewReflector().DeriveSchema(IntegerTypes{})
+
+	for _, childElem := range schema.TypeRefs.ChildByName("IntegerTypes", nil).Children {
+		got, _ := childElem.NativeDefault().Options.Get("Unsigned")
+		want := ""
+		if strings.HasPrefix(childElem.Name, "Uint") {
+			want = "true"
+		}
+		if got != want {
+			t.Errorf("TEST_FAIL unsigned: %s: got %q, want %q", childElem.Name, got, want)
+		}
+	}
+
+	// Unsigned integers have a minimum of 0 and unsigned proto types.
+	gotStrings, _ := renderer.NewJSONSchemaRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "unsigned: json schema", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "allOf": [`,
+		`    {`,
+		`      "$ref": "#/definitions/IntegerTypes"`,
+		`    }`,
+		`  ],`,
+		`  "definitions": {`,
+		`    "IntegerTypes": {`,
+		`      "properties": {`,
+		`        "Int": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Int16": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Int32": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Int64": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Int8": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Uint": {`,
+		`          "minimum": 0,`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Uint16": {`,
+		`          "minimum": 0,`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Uint32": {`,
+		`          "minimum": 0,`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Uint64": {`,
+		`          "minimum": 0,`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Uint8": {`,
+		`          "minimum": 0,`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "Uintptr": {`,
+		`          "minimum": 0,`,
+		`          "type": "integer"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "Int",`,
+		`        "Int16",`,
+		`        "Int32",`,
+		`        "Int64",`,
+		`        "Int8",`,
+		`        "Uint",`,
+		`        "Uint16",`,
+		`        "Uint32",`,
+		`        "Uint64",`,
+		`        "Uint8",`,
+		`        "Uintptr"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+
+	gotStrings, _ = renderer.NewProtoRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "unsigned: proto", gotStrings, []string{
+		`syntax = "proto3";`,
+		``,
+		`message IntegerTypes {`,
+		`  int64 Int = 14773;`,
+		`  int32 Int16 = 7762;`,
+		`  int32 Int32 = 12624;`,
+		`  int64 Int64 = 8861;`,
+		`  int32 Int8 = 10180;`,
+		`  uint64 Uint = 10529;`,
+		`  uint32 Uint16 = 5222;`,
+		`  uint32 Uint32 = 1436;`,
+		`  uint64 Uint64 = 17114;`,
+		`  uint32 Uint8 = 12750;`,
+		`  uint64 Uintptr = 8659;`,
+		`}`,
+	})
+}
+
+// Shape is an interface with two implementations.
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c *Circle) Area() float64 { return 3.14 * c.Radius * c.Radius }
+
+type Square struct {
+	Side float64 `json:"side"`
+}
+
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+// UnionStruct has interface fields with registered implementations.
+type UnionStruct struct {
+	Shape  Shape   `json:"shape"`
+	Shapes []Shape `json:"shapes"`
+}
+
+func TestReflector_RecordInterfaceAsUnion(t *testing.T) {
+	r := NewReflector()
+	r.RecordInterfaceAsUnion = true
+	r.RegisterInterfaceImpl("Shape", &Circle{}, &Square{})
+	schema := r.DeriveSchema(UnionStruct{Shape: &Circle{Radius: 1}})
+
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "union: simple", gotStrings, []string{
+		`TypeRefs.Circle:{}`,
+		`TypeRefs.Circle:{}.Radius:float`,
+		`TypeRefs.Shape:interface`,
+		`TypeRefs.Shape:interface.{}:Circle`,
+		`TypeRefs.Shape:interface.{}:Square`,
+		`TypeRefs.Square:{}`,
+		`TypeRefs.Square:{}.Side:float`,
+		`TypeRefs.UnionStruct:{}`,
+		`TypeRefs.UnionStruct:{}.Shape:interface:Shape`,
+		`TypeRefs.UnionStruct:{}.Shapes:[]`,
+		`TypeRefs.UnionStruct:{}.Shapes:[].interface:Shape`,
+		`Root.{}:UnionStruct`,
+	})
+
+	gotStrings, _ = renderer.NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	compareStrings(t, "union: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    Circle:`,
+		`      type: object`,
+		`      properties:`,
+		`        radius:`,
+		`          type: number`,
+		`          format: double`,
+		`      required:`,
+		`        - radius`,
+		`    Shape:`,
+		`      oneOf:`,
+		`        - $ref: '#/components/schemas/Circle'`,
+		`        - $ref: '#/components/schemas/Square'`,
+		`    Square:`,
+		`      type: object`,
+		`      properties:`,
+		`        side:`,
+		`          type: number`,
+		`          format: double`,
+		`      required:`,
+		`        - side`,
+		`    UnionStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        shape:`,
+		`          $ref: '#/components/schemas/Shape'`,
+		`        shapes:`,
+		`          type: array`,
+		`          items:`,
+		`            $ref: '#/components/schemas/Shape'`,
+		`      required:`,
+		`        - shapes`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/UnionStruct'`,
+	})
+
+	opt := renderer.NewOptions()
+	opt.DeReference = true
+	gotStrings, _ = renderer.NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "union: openapi deref", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  shape:`,
+		`                    oneOf:`,
+		`                      - type: object`,
+		`                        properties:`,
+		`                          radius:`,
+		`                            type: number`,
+		`                            format: double`,
+		`                        required:`,
+		`                          - radius`,
+		`                      - type: object`,
+		`                        properties:`,
+		`                          side:`,
+		`                            type: number`,
+		`                            format: double`,
+		`                        required:`,
+		`                          - side`,
+		`                  shapes:`,
+		`                    type: array`,
+		`                    items:`,
+		`                      oneOf:`,
+		`                        - type: object`,
+		`                          properties:`,
+		`                            radius:`,
+		`                              type: number`,
+		`                              format: double`,
+		`                          required:`,
+		`                            - radius`,
+		`                        - type: object`,
+		`                          properties:`,
+		`                            side:`,
+		`                              type: number`,
+		`                              format: double`,
+		`                          required:`,
+		`                            - side`,
+		`                required:`,
+		`                  - shapes`,
+	})
+
+	gotStrings, _ = renderer.NewJSONSchemaRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "union: json schema", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "shape": {`,
+		`      "oneOf": [`,
+		`        {`,
+		`          "properties": {`,
+		`            "radius": {`,
+		`              "type": "number"`,
+		`            }`,
+		`          },`,
+		`          "required": [`,
+		`            "radius"`,
+		`          ],`,
+		`          "type": "object"`,
+		`        },`,
+		`        {`,
+		`          "properties": {`,
+		`            "side": {`,
+		`              "type": "number"`,
+		`            }`,
+		`          },`,
+		`          "required": [`,
+		`            "side"`,
+		`          ],`,
+		`          "type": "object"`,
+		`        }`,
+		`      ]`,
+		`    },`,
+		`    "shapes": {`,
+		`      "items": {`,
+		`        "oneOf": [`,
+		`          {`,
+		`            "properties": {`,
+		`              "radius": {`,
+		`                "type": "number"`,
+		`              }`,
+		`            },`,
+		`            "required": [`,
+		`              "radius"`,
+		`            ],`,
+		`            "type": "object"`,
+		`          },`,
+		`          {`,
+		`            "properties": {`,
+		`              "side": {`,
+		`                "type": "number"`,
+		`              }`,
+		`            },`,
+		`            "required": [`,
+		`              "side"`,
+		`            ],`,
+		`            "type": "object"`,
+		`          }`,
+		`        ]`,
+		`      },`,
+		`      "type": "array"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "shapes"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+
+	// Interfaces without registered implementations are reflected by their value.
+	r = NewReflector()
+	r.RecordInterfaceAsUnion = true
+	gotStrings, _ = renderer.NewSimpleRenderer(nil).ProcessResult(r.DeriveSchema(UnionStruct{Shape: &Circle{Radius: 1}}))
+	wantStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(NewReflector().DeriveSchema(UnionStruct{Shape: &Circle{Radius: 1}}))
+	compareStrings(t, "union: not registered", gotStrings, wantStrings)
+}
+
+// ByteID is a named byte slice.
+type ByteID []byte
+
+// ByteStringStruct has byte and rune lists.
+type ByteStringStruct struct {
+	Bytes  []byte
+	Uint8s []uint8
+	Named  ByteID
+	Fixed  [16]byte
+	Runes  []rune
+}
+
+func TestReflector_ByteStrings(t *testing.T) {
+	opt := renderer.NewOptions()
+	opt.DeReference = true
+
+	r := NewReflector()
+	gotStrings, _ := renderer.NewSimpleRenderer(opt).ProcessResult(r.DeriveSchema(ByteStringStruct{}))
+	compareStrings(t, "byte-strings: default", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.Bytes:string`,
+		`Root.{}.Fixed:[]`,
+		`Root.{}.Fixed:[].integer`,
+		`Root.{}.Named:string`,
+		`Root.{}.Runes:[]`,
+		`Root.{}.Runes:[].integer`,
+		`Root.{}.Uint8s:string`,
+	})
+
+	r = NewReflector()
+	r.RuneAsString = true
+	schema := r.DeriveSchema(ByteStringStruct{})
+	gotStrings, _ = renderer.NewSimpleRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "byte-strings: runes", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.Bytes:string`,
+		`Root.{}.Fixed:[]`,
+		`Root.{}.Fixed:[].integer`,
+		`Root.{}.Named:string`,
+		`Root.{}.Runes:string`,
+		`Root.{}.Uint8s:string`,
+	})
+
+	// Byte strings have the byte format. Rune strings have no format.
+	root := schema.Root.Children[0]
+	for name, want := range map[string]string{"Bytes": "byte", "Uint8s": "byte", "Named": "byte", "Runes": ""} {
+		got, _ := root.ChildByName(name, nil).NativeDefault().Options.Get("Format")
+		if got != want {
+			t.Errorf("TEST_FAIL byte-strings: %s format: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+// GetterStruct has fields and getter methods.
+type GetterStruct struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+}
+
+func (g GetterStruct) FullName() string         { return g.First + " " + g.Last }
+func (g *GetterStruct) Initials() string        { return g.First[:1] + g.Last[:1] }
+func (g GetterStruct) Status() StatusEnum       { return "active" }
+func (g GetterStruct) UpdatedAt() time.Time     { return time.Time{} }
+func (g GetterStruct) String() string           { return g.FullName() }
+func (g GetterStruct) Greet(name string) string { return "Hello " + name }
+func (g GetterStruct) Split() (string, string)  { return g.First, g.Last }
+func (g GetterStruct) Names() []string          { return []string{g.First, g.Last} }
+
+func TestReflector_IncludeGetterMethods(t *testing.T) {
+	opt := renderer.NewOptions()
+	opt.DeReference = true
+
+	r := NewReflector()
+	gotStrings, _ := renderer.NewSimpleRenderer(opt).ProcessResult(r.DeriveSchema(GetterStruct{}))
+	compareStrings(t, "getters: default", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.First:string`,
+		`Root.{}.Last:string`,
+	})
+
+	r = NewReflector()
+	r.IncludeGetterMethods = true
+	schema := r.DeriveSchema(GetterStruct{})
+	gotStrings, _ = renderer.NewSimpleRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "getters: included", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.First:string`,
+		`Root.{}.FullName:string`,
+		`Root.{}.Initials:string`,
+		`Root.{}.Last:string`,
+		`Root.{}.Status:string`,
+		`Root.{}.UpdatedAt:datetime`,
+	})
+
+	// Getters are read-only so they are dropped from request variants.
+	gotStrings, _ = renderer.NewSimpleRenderer(opt).ProcessResult(schema.Variant(types.RequestVariant))
+	compareStrings(t, "getters: request", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.First:string`,
+		`Root.{}.Last:string`,
+	})
+}
+
+// TimeCollectionStruct has lists and maps of time.Time.
+type TimeCollectionStruct struct {
+	Times    []time.Time
+	TimePtrs []*time.Time
+	Array    [2]time.Time
+	ByName   map[string]time.Time
+}
+
+func TestReflector_TimeCollections(t *testing.T) {
+	opt := renderer.NewOptions()
+	opt.DeReference = true
+
+	// Empty lists and nil maps are typed from their element type.
+	r := NewReflector()
+	gotStrings, _ := renderer.NewSimpleRenderer(opt).ProcessResult(r.DeriveSchema(TimeCollectionStruct{}))
+	compareStrings(t, "time-collections: empty", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.Array:[]`,
+		`Root.{}.Array:[].datetime`,
+		`Root.{}.ByName:{}`,
+		`Root.{}.ByName:{}.datetime`,
+		`Root.{}.TimePtrs:[]`,
+		`Root.{}.TimePtrs:[].datetime`,
+		`Root.{}.Times:[]`,
+		`Root.{}.Times:[].datetime`,
+	})
+
+	r = NewReflector()
+	now := time.Now()
+	gotStrings, _ = renderer.NewSimpleRenderer(opt).ProcessResult(r.DeriveSchema(TimeCollectionStruct{
+		Times:    []time.Time{now},
+		TimePtrs: []*time.Time{&now, nil},
+		ByName:   map[string]time.Time{"created": now},
+	}))
+	compareStrings(t, "time-collections: values", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.Array:[]`,
+		`Root.{}.Array:[].datetime`,
+		`Root.{}.ByName:{}`,
+		`Root.{}.ByName:{}.datetime`,
+		`Root.{}.TimePtrs:[]`,
+		`Root.{}.TimePtrs:[].datetime`,
+		`Root.{}.Times:[]`,
+		`Root.{}.Times:[].datetime`,
+	})
+}
+
+// UntaggedStruct has no json tags.
+type UntaggedStruct struct {
+	Name  string
+	Inner StringStruct
+	Tags  []string `json:",omitempty"`
+}
+
+func TestReflector_JSONDialect(t *testing.T) {
+	schema := NewReflector().DeriveSchema(UntaggedStruct{})
+
+	// Every struct field has a json native type named after the field.
+	for _, childElem := range schema.TypeRefs.ChildByName("UntaggedStruct", nil).Children {
+		jsonNative := childElem.Native["json"]
+		if jsonNative == nil {
+			t.Errorf("TEST_FAIL json-dialect: %s: json native type not found", childElem.Name)
+		} else if jsonNative.Name != childElem.Name {
+			t.Errorf("TEST_FAIL json-dialect: %s: got name %q, want %q", childElem.Name, jsonNative.Name, childElem.Name)
+		}
+	}
+
+	gotStrings, _ := renderer.NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	compareStrings(t, "json-dialect: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    StringStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Value:`,
+		`          type: string`,
+		`      required:`,
+		`        - Value`,
+		`    UntaggedStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Inner:`,
+		`          $ref: '#/components/schemas/StringStruct'`,
+		`        Name:`,
+		`          type: string`,
+		`        Tags:`,
+		`          type: array`,
+		`          items:`,
+		`            type: string`,
+		`      required:`,
+		`        - Inner`,
+		`        - Name`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/UntaggedStruct'`,
+	})
+}
+
+// ShapedMoney is marshaled as a decimal string. The blank field declares the shape for every use.
+type ShapedMoney struct {
+	_     struct{} `jsonshape:"string"`
+	Units int64
+	Nanos int32
+}
+
+func (m ShapedMoney) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%d.%09d", m.Units, m.Nanos))
+}
+
+// ShapedValues is marshaled as an object or an array depending on the field.
+type ShapedValues struct {
+	Values map[string]int
+}
+
+// JSONShapeStruct has fields with custom MarshalJSON shapes.
+type JSONShapeStruct struct {
+	Price    ShapedMoney  `json:"price"`
+	PricePtr *ShapedMoney `json:"pricePtr"`
+	List     ShapedValues `json:"list" jsonshape:"array"`
+	Object   ShapedValues `json:"object" jsonshape:"object"`
+	Unknown  ShapedValues `json:"unknown" jsonshape:"number"`
+}
+
+func TestReflector_JSONShape(t *testing.T) {
+	r := NewReflector()
+	schema := r.DeriveSchema(JSONShapeStruct{})
+
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "json-shape: simple", gotStrings, []string{
+		`TypeRefs.JSONShapeStruct:{}`,
+		`TypeRefs.JSONShapeStruct:{}.List:[]`,
+		`TypeRefs.JSONShapeStruct:{}.List:[].interface`,
+		`TypeRefs.JSONShapeStruct:{}.Object:{}`,
+		`TypeRefs.JSONShapeStruct:{}.Price:string:ShapedMoney`,
+		`TypeRefs.JSONShapeStruct:{}.PricePtr:string:ShapedMoney`,
+		`TypeRefs.JSONShapeStruct:{}.!Unknown:{}! ERROR:jsonshape must be string, object or array`,
+		`TypeRefs.ShapedMoney:string`,
+		`Root.{}:JSONShapeStruct`,
+	})
+
+	render := renderer.NewOpenAPIRenderer("/test/path", nil)
+	render.NullableKeyword = renderer.NullableOpenAPI3
+	gotStrings, _ = render.ProcessResult(schema)
+	compareStrings(t, "json-shape: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    JSONShapeStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        list:`,
+		`          type: array`,
+		`          items: {}`,
+		`        object:`,
+		`          type: object`,
+		`        price:`,
+		`          $ref: '#/components/schemas/ShapedMoney'`,
+		`        pricePtr:`,
+		`          nullable: true`,
+		`          allOf:`,
+		`            - $ref: '#/components/schemas/ShapedMoney'`,
+		`        unknown:`,
+		`          type: object`,
+		`          error: jsonshape must be string, object or array`,
+		`      required:`,
+		`        - list`,
+		`        - object`,
+		`        - price`,
+		`        - unknown`,
+		`    ShapedMoney:`,
+		`      type: string`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/JSONShapeStruct'`,
+	})
+}
+
+// NonStringKeyStruct has maps with keys that are not strings.
+type NonStringKeyStruct struct {
+	ByID     map[int]GoodEntity
+	ByFlag   map[bool]string
+	ByCode   map[uint8]interface{}
+	ByStruct map[StringStruct]string
+}
+
+func TestReflector_AllowNonStringMapKeys(t *testing.T) {
+	value := NonStringKeyStruct{ByCode: map[uint8]interface{}{1: "one", 2: 2.0}}
+
+	// Default is an error for keys that are not strings.
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(NewReflector().DeriveSchema(value))
+	compareStrings(t, "non-string-keys: default", gotStrings, []string{
+		`TypeRefs.NonStringKeyStruct:{}`,
+		`TypeRefs.NonStringKeyStruct:{}.!ByCode:{}! ERROR:map key type must be string`,
+		`TypeRefs.NonStringKeyStruct:{}.!ByFlag:{}! ERROR:map key type must be string`,
+		`TypeRefs.NonStringKeyStruct:{}.!ByID:{}! ERROR:map key type must be string`,
+		`TypeRefs.NonStringKeyStruct:{}.!ByStruct:{}! ERROR:map key type must be string`,
+		`Root.{}:NonStringKeyStruct`,
+	})
+
+	// Basic keys are allowed. Other keys are still an error.
+	r := NewReflector()
+	r.AllowNonStringMapKeys = true
+	schema := r.DeriveSchema(value)
+
+	gotStrings, _ = renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "non-string-keys: allowed", gotStrings, []string{
+		`TypeRefs.GoodEntity:{}`,
+		`TypeRefs.GoodEntity:{}.IntVal:integer`,
+		`TypeRefs.GoodEntity:{}.Message:string`,
+		`TypeRefs.GoodEntity:{}.Same:boolean`,
+		`TypeRefs.NonStringKeyStruct:{}`,
+		`TypeRefs.NonStringKeyStruct:{}.ByCode:{}`,
+		`TypeRefs.NonStringKeyStruct:{}.ByCode:{}.1:string`,
+		`TypeRefs.NonStringKeyStruct:{}.ByCode:{}.2:float`,
+		`TypeRefs.NonStringKeyStruct:{}.ByFlag:{}`,
+		`TypeRefs.NonStringKeyStruct:{}.ByFlag:{}.string`,
+		`TypeRefs.NonStringKeyStruct:{}.ByID:{}`,
+		`TypeRefs.NonStringKeyStruct:{}.ByID:{}.{}:GoodEntity`,
+		`TypeRefs.NonStringKeyStruct:{}.!ByStruct:{}! ERROR:map key type must be string`,
+		`Root.{}:NonStringKeyStruct`,
+	})
+
+	// Key types are recorded for renderers.
+	byID := schema.TypeRefs.ChildByName("NonStringKeyStruct", nil).ChildByName("ByID", nil)
+	if got, _ := byID.NativeDefault().Options.Get("MapKeyType"); got != "integer" {
+		t.Errorf("TEST_FAIL non-string-keys: MapKeyType: got %q, want %q", got, "integer")
+	}
+}
+
+// Unexported named types for OnlyExportedRefs.
+type privateNamed struct {
+	Value string
+}
+
+type privateNode struct {
+	Name string
+	Next *privateNode
+}
+
+type privateCode string
+
+// OnlyExportedRefsStruct has fields of exported and unexported named types.
+type OnlyExportedRefsStruct struct {
+	Private privateNamed
+	Node    privateNode
+	Code    privateCode
+	Public  StringStruct
+	Empty   PrivateStruct
+}
+
+func TestReflector_OnlyExportedRefs(t *testing.T) {
+	r := NewReflector()
+	r.OnlyExportedRefs = true
+
+	// PrivateStruct has no exported fields but its name is exported so it is still a TypeRef.
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(r.DeriveSchema(OnlyExportedRefsStruct{}))
+	compareStrings(t, "only-exported-refs", gotStrings, []string{
+		`TypeRefs.OnlyExportedRefsStruct:{}`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Code:string`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Empty:{}:PrivateStruct`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Node:{}`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Node:{}.Name:string`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Node:{}.!Next:{}! ERROR:cyclical reference`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Private:{}`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Private:{}.Value:string`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Public:{}:StringStruct`,
+		`TypeRefs.!PrivateStruct:{}! ERROR:struct has no exported fields`,
+		`TypeRefs.StringStruct:{}`,
+		`TypeRefs.StringStruct:{}.Value:string`,
+		`Root.{}:OnlyExportedRefsStruct`,
+	})
+
+	// Default is a TypeRef for every named type.
+	gotStrings, _ = renderer.NewSimpleRenderer(nil).ProcessResult(NewReflector().DeriveSchema(OnlyExportedRefsStruct{}))
+	compareStrings(t, "only-exported-refs: default", gotStrings, []string{
+		`TypeRefs.OnlyExportedRefsStruct:{}`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Code:string:privateCode`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Empty:{}:PrivateStruct`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Node:{}:privateNode`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Private:{}:privateNamed`,
+		`TypeRefs.OnlyExportedRefsStruct:{}.Public:{}:StringStruct`,
+		`TypeRefs.!PrivateStruct:{}! ERROR:struct has no exported fields`,
+		`TypeRefs.StringStruct:{}`,
+		`TypeRefs.StringStruct:{}.Value:string`,
+		`TypeRefs.privateCode:string`,
+		`TypeRefs.privateNamed:{}`,
+		`TypeRefs.privateNamed:{}.Value:string`,
+		`TypeRefs.privateNode:{}`,
+		`TypeRefs.privateNode:{}.Name:string`,
+		`TypeRefs.privateNode:{}.Next:{}:privateNode`,
+		`Root.{}:OnlyExportedRefsStruct`,
+	})
+}
+
+// MarshalerUUID is a UUID that is marshaled as text.
+type MarshalerUUID [16]byte
+
+func (u MarshalerUUID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%x", u[:])), nil
+}
+
+// MarshalerID is an ID that is marshaled as a JSON string.
+type MarshalerID struct {
+	Prefix string
+	Number int
+}
+
+func (id *MarshalerID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%s-%d", id.Prefix, id.Number))
+}
+
+// MarshalerStruct has fields with custom marshalers.
+type MarshalerStruct struct {
+	UUID  MarshalerUUID `json:"uuid"`
+	ID    MarshalerID   `json:"id"`
+	IDPtr *MarshalerID  `json:"idPtr"`
+}
+
+func TestReflector_RespectMarshalers(t *testing.T) {
+	schema := NewReflector().DeriveSchema(MarshalerStruct{})
+
+	// Marshalers are strings by default.
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "respect-marshalers: default", gotStrings, []string{
+		`TypeRefs.MarshalerID:string`,
+		`TypeRefs.MarshalerStruct:{}`,
+		`TypeRefs.MarshalerStruct:{}.ID:string:MarshalerID`,
+		`TypeRefs.MarshalerStruct:{}.IDPtr:string:MarshalerID`,
+		`TypeRefs.MarshalerStruct:{}.UUID:string:MarshalerUUID`,
+		`TypeRefs.MarshalerUUID:string`,
+		`Root.{}:MarshalerStruct`,
+	})
+
+	if got, _ := schema.TypeRefs.ChildByName("MarshalerUUID", nil).NativeDefault().Options.Get("Marshaler"); got != "encoding.TextMarshaler" {
+		t.Errorf("TEST_FAIL respect-marshalers: Marshaler: got %q, want %q", got, "encoding.TextMarshaler")
+	}
+
+	// Go types are reflected if marshalers are not respected.
+	r := NewReflector()
+	r.RespectMarshalers = false
+	gotStrings, _ = renderer.NewSimpleRenderer(nil).ProcessResult(r.DeriveSchema(MarshalerStruct{}))
+	compareStrings(t, "respect-marshalers: disabled", gotStrings, []string{
+		`TypeRefs.MarshalerID:{}`,
+		`TypeRefs.MarshalerID:{}.Number:integer`,
+		`TypeRefs.MarshalerID:{}.Prefix:string`,
+		`TypeRefs.MarshalerStruct:{}`,
+		`TypeRefs.MarshalerStruct:{}.ID:{}:MarshalerID`,
+		`TypeRefs.MarshalerStruct:{}.IDPtr:{}:MarshalerID`,
+		`TypeRefs.MarshalerStruct:{}.UUID:[]:MarshalerUUID`,
+		`TypeRefs.MarshalerUUID:[]`,
+		`TypeRefs.MarshalerUUID:[].integer`,
+		`Root.{}:MarshalerStruct`,
+	})
+}
+
+// SemVer is a struct that is serialized as a string, e.g. "1.2.3".
+type SemVer struct {
+	Major, Minor, Patch int
+}
+
+// KnownTypeStruct has a field of a type that is configured as a known type.
+type KnownTypeStruct struct {
+	Version    SemVer
+	VersionPtr *SemVer
+}
+
+func TestReflector_Options(t *testing.T) {
+	// Options set the same fields as direct assignment.
+	r := NewReflector()
+	r.RespectMarshalers = false
+	wantStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(r.DeriveSchema(MarshalerStruct{}))
+
+	r = NewReflector(WithRespectMarshalers(false))
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(r.DeriveSchema(MarshalerStruct{}))
+	compareStrings(t, "options: respect-marshalers", gotStrings, wantStrings)
+
+	// Known types are formatted strings instead of TypeRefs.
+	r = NewReflector(WithKnownType("github.com/gitmann/b9schema-reflector-golang/reflector", "SemVer", "semver"))
+	schema := r.DeriveSchema(KnownTypeStruct{})
+	gotStrings, _ = renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "options: known-type", gotStrings, []string{
+		`TypeRefs.KnownTypeStruct:{}`,
+		`TypeRefs.KnownTypeStruct:{}.Version:string`,
+		`TypeRefs.KnownTypeStruct:{}.VersionPtr:string`,
+		`Root.{}:KnownTypeStruct`,
+	})
+
+	if got, _ := schema.TypeRefs.ChildByName("KnownTypeStruct", nil).ChildByName("Version", nil).NativeDefault().Options.Get("Format"); got != "semver" {
+		t.Errorf("TEST_FAIL options: known-type: Format: got %q, want %q", got, "semver")
+	}
+
+	// Reset keeps options.
+	r.Reset()
+	resetStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(r.DeriveSchema(KnownTypeStruct{}))
+	compareStrings(t, "options: reset", resetStrings, gotStrings)
+}
+
+// SQLNullStruct has fields of database/sql null wrappers.
+type SQLNullStruct struct {
+	Name    sql.NullString  `json:"name"`
+	Count   sql.NullInt64   `json:"count"`
+	Score   sql.NullFloat64 `json:"score"`
+	Active  sql.NullBool    `json:"active"`
+	Updated sql.NullTime    `json:"updated"`
+}
+
+func TestReflector_SQLNullTypes(t *testing.T) {
+	// Without the option, wrappers are structs.
+	schema := NewReflector().DeriveSchema(SQLNullStruct{})
+	if got := schema.TypeRefs.ChildByName("SQLNullStruct", nil).ChildByName("Name", nil).Type; got != generictype.Struct.String() {
+		t.Errorf("TEST_FAIL sql-null: default: got %q, want %q", got, generictype.Struct.String())
+	}
+
+	schema = NewReflector(WithSQLNullTypes(true)).DeriveSchema(SQLNullStruct{})
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "sql-null: simple", gotStrings, []string{
+		`TypeRefs.SQLNullStruct:{}`,
+		`TypeRefs.SQLNullStruct:{}.Active:boolean`,
+		`TypeRefs.SQLNullStruct:{}.Count:integer`,
+		`TypeRefs.SQLNullStruct:{}.Name:string`,
+		`TypeRefs.SQLNullStruct:{}.Score:float`,
+		`TypeRefs.SQLNullStruct:{}.Updated:datetime`,
+		`Root.{}:SQLNullStruct`,
+	})
+
+	for _, childElem := range schema.TypeRefs.ChildByName("SQLNullStruct", nil).Children {
+		if !childElem.Nullable {
+			t.Errorf("TEST_FAIL sql-null: %s is not nullable", childElem.Name)
+		}
+	}
+
+	opt := renderer.NewOptions()
+	opt.DeReference = true
+	render := renderer.NewOpenAPIRenderer("/test/path", opt)
+	render.NullableKeyword = renderer.NullableOpenAPI3
+	gotStrings, _ = render.ProcessResult(schema)
+	compareStrings(t, "sql-null: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  active:`,
+		`                    nullable: true`,
+		`                    type: boolean`,
+		`                  count:`,
+		`                    nullable: true`,
+		`                    type: integer`,
+		`                  name:`,
+		`                    nullable: true`,
+		`                    type: string`,
+		`                  score:`,
+		`                    nullable: true`,
+		`                    type: number`,
+		`                  updated:`,
+		`                    nullable: true`,
+		`                    type: string`,
+		`                    format: date-time`,
+	})
+}
+
+func TestReflector_DeriveSchemaFromType(t *testing.T) {
+	tests := []interface{}{
+		BasicStruct{},
+		CycleTest{},
+		&CycleTest{},
+	}
+
+	for _, value := range tests {
+		testName := fmt.Sprintf("derive-from-type: %T", value)
+
+		fromValue := NewReflector().DeriveSchema(value)
+		fromType := NewReflector().DeriveSchemaFromType(reflect.TypeOf(value))
+
+		// Both entry points must capture the same TypeRefs and cycles.
+		for i := 0; i < 2; i++ {
+			opt := renderer.NewOptions()
+			opt.DeReference = i == 1
+
+			wantStrings, _ := renderer.NewSimpleRenderer(opt).ProcessResult(fromValue)
+			gotStrings, _ := renderer.NewSimpleRenderer(opt).ProcessResult(fromType)
+			compareStrings(t, fmt.Sprintf("%s: deref=%t", testName, opt.DeReference), gotStrings, wantStrings)
+		}
+
+		if fromType.Hash() != fromValue.Hash() {
+			t.Errorf("TEST_FAIL %s: hash differs", testName)
+		}
+	}
+
+	// A nil type is an invalid root like a nil value.
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(NewReflector().DeriveSchemaFromType(nil))
+	wantStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(NewReflector().DeriveSchema(nil))
+	compareStrings(t, "derive-from-type: nil", gotStrings, wantStrings)
+}
+
+func TestReflector_DeriveSchemaFromJSONStream(t *testing.T) {
+	document := `{"MapOK": {"StringVal": "Hello", "IntVal": 123, "ListVal": [2, 3], "MapVal": {"Key1": "Hey", "Key2": {"DeepKey": 234}}}}`
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(document), &decoded); err != nil {
+		t.Fatalf("TEST_FAIL json-stream: document: %s", err)
+	}
+
+	// A single document is reflected like its decoded value.
+	r := NewReflector()
+	schema, err := r.DeriveSchemaFromJSONStream(strings.NewReader(document))
+	if err != nil {
+		t.Errorf("TEST_FAIL json-stream: document: unexpected error: %s", err)
+	}
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	wantStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(NewReflector().DeriveSchema(decoded))
+	compareStrings(t, "json-stream: document", gotStrings, wantStrings)
+
+	// NDJSON records are merged. Nulls take the type of other values.
+	ndjson := `{"id": "a", "tags": ["x"], "score": null}
+{"id": "b", "tags": [], "score": 1.5, "extra": {"ok": true}}
+{"id": 3, "tags": ["y", "z"]}
+`
+	r = NewReflector()
+	schema, err = r.DeriveSchemaFromJSONStream(strings.NewReader(ndjson))
+	if err != nil {
+		t.Errorf("TEST_FAIL json-stream: ndjson: unexpected error: %s", err)
+	}
+	gotStrings, _ = renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "json-stream: ndjson", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.Extra:{}`,
+		`Root.{}.Extra:{}.Ok:boolean`,
+		`Root.{}.!Id:string! ERROR:conflicting JSON types`,
+		`Root.{}.Score:float`,
+		`Root.{}.Tags:[]`,
+		`Root.{}.Tags:[].string`,
+	})
+
+	// Fields that are null in some records are nullable.
+	for name, want := range map[string]bool{"Id": false, "Score": true} {
+		if got := schema.Root.Children[0].ChildByName(name, nil).Nullable; got != want {
+			t.Errorf("TEST_FAIL json-stream: ndjson: %s Nullable: got %t, want %t", name, got, want)
+		}
+	}
+
+	// Invalid and empty streams are errors.
+	if _, err := NewReflector().DeriveSchemaFromJSONStream(strings.NewReader(`{"id": `)); err == nil {
+		t.Errorf("TEST_FAIL json-stream: invalid: got nil error")
+	}
+	if _, err := NewReflector().DeriveSchemaFromJSONStream(strings.NewReader(``)); err == nil {
+		t.Errorf("TEST_FAIL json-stream: empty: got nil error")
+	}
+}
+
+// EmbeddingStruct embeds BasicStruct. Its fields are promoted like encoding/json promotes them.
+type EmbeddingStruct struct {
+	BasicStruct
+	*StringStruct
+	Extra   ExtraFields `json:",inline"`
+	Named   BasicStruct `json:"named"`
+	IntVal  string      `json:"intVal"`
+	OwnName string
+}
+
+// ExtraFields is inlined in EmbeddingStruct.
+type ExtraFields struct {
+	Note string `json:"note"`
+
+	// OwnName collides with the outer field.
+	OwnName int
+}
+
+func TestReflector_EmbeddedStruct(t *testing.T) {
+	schema := NewReflector().DeriveSchema(EmbeddingStruct{})
+
+	gotStrings, _ := renderer.NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "embedded: simple", gotStrings, []string{
+		`TypeRefs.BasicStruct:{}`,
+		`TypeRefs.BasicStruct:{}.BoolVal:boolean`,
+		`TypeRefs.BasicStruct:{}.Float64Val:float`,
+		`TypeRefs.BasicStruct:{}.IntVal:integer`,
+		`TypeRefs.BasicStruct:{}.StringVal:string`,
+		`TypeRefs.EmbeddingStruct:{}`,
+		`TypeRefs.EmbeddingStruct:{}.BoolVal:boolean`,
+		`TypeRefs.EmbeddingStruct:{}.Float64Val:float`,
+		`TypeRefs.EmbeddingStruct:{}.IntVal:string`,
+		`TypeRefs.EmbeddingStruct:{}.Named:{}:BasicStruct`,
+		`TypeRefs.EmbeddingStruct:{}.Note:string`,
+		`TypeRefs.EmbeddingStruct:{}.OwnName:string`,
+		`TypeRefs.EmbeddingStruct:{}.StringVal:string`,
+		`TypeRefs.EmbeddingStruct:{}.Value:string`,
+		`Root.{}:EmbeddingStruct`,
+	})
+}
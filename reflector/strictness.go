@@ -0,0 +1,34 @@
+package reflector
+
+// Strictness bundles reflection behaviors for common cases.
+// - Granular options (e.g. AllowEmptyStruct) still apply at every level.
+type Strictness int
+
+const (
+	// Normal records problems as element errors and keeps reflecting. This is the default.
+	Normal Strictness = iota
+
+	// Lenient reflects as much as possible without errors:
+	// - nil interfaces are untyped (any value) instead of an error
+	// - struct fields with errors are removed
+	Lenient
+
+	// Strict stops reflection at the first element error.
+	// - DeriveSchema returns the partial schema which includes the element error.
+	// - DeriveSchemaWithContext returns the element error.
+	// - Cyclical references are not errors.
+	Strict
+)
+
+func (s Strictness) String() string {
+	switch s {
+	case Normal:
+		return "normal"
+	case Lenient:
+		return "lenient"
+	case Strict:
+		return "strict"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,91 @@
+//go:build go1.18
+
+package renderer
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/reflector"
+	"testing"
+)
+
+// Response is a generic result type.
+type Response[T any] struct {
+	Data  T
+	Error string
+}
+
+// User is the data of a Response.
+type User struct {
+	Name string
+}
+
+// Order is the data of another Response.
+type Order struct {
+	Total float64
+}
+
+// GenericResponses has two instantiations of Response.
+type GenericResponses struct {
+	User  Response[User]
+	Order Response[Order]
+}
+
+func TestRenderer_GenericResponse(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(GenericResponses{})
+
+	// Each instantiation is a TypeRef with a sanitized name.
+	gotStrings, _ := NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "generic response: simple", gotStrings, []string{
+		`TypeRefs.GenericResponses:{}`,
+		`TypeRefs.GenericResponses:{}.Order:{}:ResponseOrder`,
+		`TypeRefs.GenericResponses:{}.User:{}:ResponseUser`,
+		`TypeRefs.Order:{}`,
+		`TypeRefs.Order:{}.Total:float`,
+		`TypeRefs.ResponseOrder:{}`,
+		`TypeRefs.ResponseOrder:{}.Data:{}:Order`,
+		`TypeRefs.ResponseOrder:{}.Error:string`,
+		`TypeRefs.ResponseUser:{}`,
+		`TypeRefs.ResponseUser:{}.Data:{}:User`,
+		`TypeRefs.ResponseUser:{}.Error:string`,
+		`TypeRefs.User:{}`,
+		`TypeRefs.User:{}.Name:string`,
+		`Root.{}:GenericResponses`,
+	})
+
+	// Data is a reference to the type argument.
+	opt := NewOptions()
+	opt.Indent = 0
+	gotStrings, _ = NewOpenAPIRenderer("/test/path", opt).ProcessResult(reflector.NewReflector().DeriveSchema(Response[User]{}))
+	compareStrings(t, "generic response: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    ResponseUser:`,
+		`      type: object`,
+		`      properties:`,
+		`        Data:`,
+		`          $ref: '#/components/schemas/User'`,
+		`        Error:`,
+		`          type: string`,
+		`      required:`,
+		`        - Data`,
+		`        - Error`,
+		`    User:`,
+		`      type: object`,
+		`      properties:`,
+		`        Name:`,
+		`          type: string`,
+		`      required:`,
+		`        - Name`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/ResponseUser'`,
+	})
+}
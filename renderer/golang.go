@@ -0,0 +1,268 @@
+package renderer
+
+import (
+	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"sort"
+	"strings"
+)
+
+// GoRenderer renders Go type declarations for a Schema, e.g. to regenerate structs from a schema loaded with SchemaFromJSON.
+// - Each TypeRef is a type declaration. Struct fields are sorted by Go name and tagged with their JSON names.
+// - Nullable fields are pointers, lists are slices (or arrays with their length), and interfaces are interface{}.
+// - The root type is "Root": an alias of its TypeRef, or a declaration of an anonymous type.
+// - Fields with errors are comments. TypeRefs with errors are declared as interface{}. Types are always referenced by name so DeReference does not apply.
+type GoRenderer struct {
+	sharedAnalysis
+
+	opt *Options
+
+	// Package is the name in the package clause. Default is "schema".
+	Package string
+
+	// typeRefs maps TypeRef names to their elements while a Schema is rendered.
+	typeRefs map[string]*types.TypeElement
+
+	// imports holds the imports needed by the rendered types.
+	imports map[string]bool
+}
+
+func NewGoRenderer(opt *Options) *GoRenderer {
+	if opt == nil {
+		opt = NewOptions()
+	}
+
+	return &GoRenderer{opt: opt, Package: "schema"}
+}
+
+func (r *GoRenderer) ProcessResult(result *types.Schema) ([]string, error) {
+	r.typeRefs = r.analyze(result).typeRefs
+	r.imports = map[string]bool{}
+
+	decls := []string{}
+	for _, name := range result.TypeRefs.ChildKeys(r.typeRefs) {
+		refElem := r.typeRefs[name]
+		if refElem.Error != "" {
+			// TypeRefs with errors are placeholders so that fields and Root can still use their names.
+			decls = append(decls, "", "// "+refElem.Name+": "+refElem.Error, "type "+refElem.Name+" interface{}")
+			continue
+		}
+		decls = append(decls, "")
+		decls = append(decls, r.declLines(refElem.Name, refElem)...)
+	}
+
+	if len(result.Root.Children) > 0 {
+		rootElem := result.Root.Children[0]
+		switch {
+		case rootElem.Error != "" && rootElem.Error != types.CyclicalReferenceErr:
+			// Roots with errors have no type.
+		case rootElem.TypeRef != "" && rootElem.TypeRef != "Root":
+			decls = append(decls, "", "type Root = "+rootElem.TypeRef)
+		case rootElem.TypeRef == "":
+			decls = append(decls, "")
+			decls = append(decls, r.declLines("Root", rootElem)...)
+		}
+	}
+
+	out := bannerLines(r.opt, "//")
+	out = append(out, r.Prefix()+"package "+r.Package)
+
+	if len(r.imports) > 0 {
+		out = append(out, "")
+		importNames := []string{}
+		for name := range r.imports {
+			importNames = append(importNames, name)
+		}
+		sort.Strings(importNames)
+		for _, name := range importNames {
+			out = append(out, r.Prefix()+fmt.Sprintf("import %q", name))
+		}
+	}
+
+	for _, line := range decls {
+		if line == "" {
+			out = append(out, line)
+		} else {
+			out = append(out, r.Prefix()+line)
+		}
+	}
+
+	return out, rendererErrors(r.analyze(result), r, nil).err()
+}
+
+// declLines returns the lines of a type declaration. Declared types are not pointers even if the element is nullable.
+func (r *GoRenderer) declLines(name string, t *types.TypeElement) []string {
+	return strings.Split("type "+name+" "+r.baseType(t, 0), "\n")
+}
+
+// fieldLines returns the lines of the fields of a struct element. depth is the nesting depth of the struct.
+func (r *GoRenderer) fieldLines(t *types.TypeElement, depth int) []string {
+	indent := strings.Repeat("\t", depth+1)
+
+	fields := []*types.TypeElement{}
+	for _, childElem := range t.Children {
+		if !skipElement(childElem, r) {
+			fields = append(fields, childElem)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+
+	// Names and single-line types are aligned like gofmt aligns them.
+	fieldTypes := make([]string, len(fields))
+	nameWidth, typeWidth := 0, 0
+	for i, childElem := range fields {
+		if childElem.Error != "" && childElem.Error != types.CyclicalReferenceErr {
+			continue
+		}
+		fieldTypes[i] = r.goType(childElem, depth+1)
+		if len(childElem.Name) > nameWidth {
+			nameWidth = len(childElem.Name)
+		}
+		if !strings.Contains(fieldTypes[i], "\n") && len(fieldTypes[i]) > typeWidth {
+			typeWidth = len(fieldTypes[i])
+		}
+	}
+
+	out := []string{}
+	for i, childElem := range fields {
+		if fieldTypes[i] == "" {
+			out = append(out, fmt.Sprintf("%s// %s: %s", indent, childElem.Name, childElem.Error))
+			continue
+		}
+		width := typeWidth
+		if strings.Contains(fieldTypes[i], "\n") {
+			width = 0
+		}
+		out = append(out, fmt.Sprintf("%s%-*s %-*s %s", indent, nameWidth, childElem.Name, width, fieldTypes[i], r.fieldTag(childElem)))
+	}
+
+	return out
+}
+
+// fieldTag returns the struct tag of a field with its JSON name and options.
+func (r *GoRenderer) fieldTag(t *types.TypeElement) string {
+	jsonType := t.GetNativeType("json")
+	if jsonType.Include == threeflag.False {
+		return "`json:\"-\"`"
+	}
+
+	parts := []string{jsonType.Name}
+	for _, option := range []string{"omitempty", "string"} {
+		if _, ok := jsonType.Options.Get(option); ok {
+			parts = append(parts, option)
+		}
+	}
+
+	return "`json:\"" + strings.Join(parts, ",") + "\"`"
+}
+
+// goType returns the Go type of a field element. Nullable elements are pointers.
+func (r *GoRenderer) goType(t *types.TypeElement, depth int) string {
+	typeName := ""
+	if t.TypeRef != "" {
+		typeName = t.TypeRef
+	} else {
+		typeName = r.baseType(t, depth)
+	}
+
+	if t.Nullable && t.Type != generictype.Interface.String() {
+		return "*" + typeName
+	}
+	return typeName
+}
+
+// baseType returns the Go type of an element without a pointer. Anonymous structs span several lines.
+func (r *GoRenderer) baseType(t *types.TypeElement, depth int) string {
+	nativeType := t.NativeDefault()
+
+	switch t.SchemaType() {
+	case generictype.Struct.String():
+		if isMap(t) {
+			return "map[string]" + r.goType(t.Children[0], depth)
+		}
+		lines := []string{"struct {"}
+		lines = append(lines, r.fieldLines(t, depth)...)
+		return strings.Join(append(lines, strings.Repeat("\t", depth)+"}"), "\n")
+	case generictype.List.String():
+		itemType := "interface{}"
+		if len(t.Children) > 0 {
+			itemType = r.goType(t.Children[0], depth)
+		}
+		if nativeType.Type == "array" {
+			if arrayLen, ok := nativeType.Options.Get("Len"); ok {
+				return "[" + arrayLen + "]" + itemType
+			}
+		}
+		return "[]" + itemType
+	case generictype.Boolean.String():
+		return "bool"
+	case generictype.Integer.String():
+		switch nativeType.Type {
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr":
+			return nativeType.Type
+		}
+		if isUnsigned(t) {
+			return "uint64"
+		}
+		return "int64"
+	case generictype.Float.String():
+		if nativeType.Type == "float32" {
+			return "float32"
+		}
+		return "float64"
+	case generictype.String.String():
+		if format, _ := nativeType.Options.Get("Format"); format == "byte" {
+			return "[]byte"
+		}
+		return "string"
+	case generictype.DateTime.String():
+		r.imports["time"] = true
+		return "time.Time"
+	}
+
+	return "interface{}"
+}
+
+func (r *GoRenderer) DeReference() bool {
+	return r.opt.DeReference
+}
+
+func (r *GoRenderer) OmitErrors() bool {
+	return r.opt.OmitErrors
+}
+
+func (r *GoRenderer) TypeRenderer(typeName string) TypeRendererFunc {
+	return r.opt.TypeRenderers[typeName]
+}
+
+func (r *GoRenderer) Indent() int {
+	return r.opt.Indent
+}
+
+func (r *GoRenderer) SetIndent(value int) {
+	r.opt.Indent = value
+}
+
+func (r *GoRenderer) Prefix() string {
+	if r.opt.Prefix == "" {
+		return ""
+	}
+	return strings.Repeat(r.opt.Prefix, r.opt.Indent)
+}
+
+// Pre, Post, and Path are not used because declarations are built as a whole.
+func (r *GoRenderer) Pre(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *GoRenderer) Post(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *GoRenderer) Path(t *types.TypeElement) []string {
+	return []string{}
+}
@@ -6,6 +6,7 @@ import (
 
 type Renderer interface {
 	// ProcessResult starts the render process on a Schema and returns a slice of strings.
+	// - If any elements have errors, a best-effort result is returned with a non-nil ElementErrors.
 	ProcessResult(result *types.Schema) ([]string, error)
 
 	// DeReference returns true if schema references should be replaced with inline types.
@@ -11,6 +11,8 @@ import (
 
 // JSONRenderer provides a simple string renderer.
 type JSONRenderer struct {
+	sharedAnalysis
+
 	opt *Options
 }
 
@@ -24,7 +26,7 @@ func NewJSONRenderer(opt *Options) *JSONRenderer {
 
 func (r *JSONRenderer) ProcessResult(result *types.Schema) ([]string, error) {
 	// Header
-	return RenderSchema(result, r), nil
+	return RenderSchema(result, r), rendererErrors(r.analyze(result), r, nil).err()
 	// Footer
 }
 
@@ -32,6 +34,14 @@ func (r *JSONRenderer) DeReference() bool {
 	return r.opt.DeReference
 }
 
+func (r *JSONRenderer) OmitErrors() bool {
+	return r.opt.OmitErrors
+}
+
+func (r *JSONRenderer) TypeRenderer(typeName string) TypeRendererFunc {
+	return r.opt.TypeRenderers[typeName]
+}
+
 func (r *JSONRenderer) Indent() int {
 	return r.opt.Indent
 }
@@ -48,7 +58,7 @@ func (r *JSONRenderer) Prefix() string {
 }
 
 func (r *JSONRenderer) Pre(t *types.TypeElement) []string {
-	jsonType := t.GetNativeType("json")
+	jsonType := t.GetNativeType(r.opt.dialect())
 	if jsonType.Include == threeflag.False {
 		// Skip this element.
 		return []string{}
@@ -100,7 +110,7 @@ func (r *JSONRenderer) Path(t *types.TypeElement) []string {
 		}
 	}
 
-	jsonType := t.GetNativeType("json")
+	jsonType := t.GetNativeType(r.opt.dialect())
 	if jsonType.Include == threeflag.False {
 		// Skip this element.
 		return []string{}
@@ -116,7 +126,7 @@ func (r *JSONRenderer) Path(t *types.TypeElement) []string {
 	if t.TypeCategory == typecategory.Invalid.String() {
 		typePart = t.Type
 	} else {
-		typePart = generictype.PathDefaultOfType(jsonType.Type)
+		typePart = t.PathDefault()
 	}
 
 	// Add TypeRef suffix if set but not if de-referencing.
@@ -139,10 +149,5 @@ func (r *JSONRenderer) Path(t *types.TypeElement) []string {
 		path = fmt.Sprintf("!%s!", path)
 	}
 
-	// Wrap type in "!" if current element is an error.
-	if t.Error != "" {
-		path = fmt.Sprintf("!%s!", path)
-	}
-
 	return append(r.Path(t.Parent), path)
 }
@@ -0,0 +1,355 @@
+package renderer
+
+import (
+	"encoding/json"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/typecategory"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDraft07 is the $schema URI of documents built by JSONSchemaRenderer.
+const JSONSchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchemaRenderer renders a JSON Schema (draft-07) document.
+type JSONSchemaRenderer struct {
+	sharedAnalysis
+
+	// PropertyNames constrains map keys with a "propertyNames" schema instead of "patternProperties".
+	// - Keys are restricted to the values of a "keyEnum" tag or an enum key type, or to the pattern of a "keyPattern" tag.
+	PropertyNames bool
+
+	// AnyForNilInterface renders nil interfaces, which have no type, as an empty schema that allows any value.
+	// - Default is to render them with an error. Use this for interface{} fields that hold arbitrary JSON.
+	AnyForNilInterface bool
+
+	opt *Options
+
+	// hasCycles is set if a cyclical reference was rendered as a ref in de-reference mode.
+	hasCycles bool
+}
+
+func NewJSONSchemaRenderer(opt *Options) *JSONSchemaRenderer {
+	if opt == nil {
+		opt = NewOptions()
+	}
+
+	return &JSONSchemaRenderer{opt: opt}
+}
+
+func (r *JSONSchemaRenderer) ProcessResult(result *types.Schema) ([]string, error) {
+	doc, err := r.ProcessDocument(result)
+
+	b, marshalErr := json.MarshalIndent(doc, r.Prefix(), "  ")
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	return strings.Split(string(b), "\n"), err
+}
+
+// ProcessDocument returns the JSON Schema as a document model that can be merged or encoded by the caller.
+func (r *JSONSchemaRenderer) ProcessDocument(result *types.Schema) (map[string]interface{}, error) {
+	r.hasCycles = false
+
+	// Root schema is the first child of the root element.
+	// - Root is not nullable even if it was reflected from a pointer.
+	doc := map[string]interface{}{}
+	if len(result.Root.Children) > 0 {
+		doc = r.schemaOf(result.Root.Children[0], r.DeReference(), false)
+	}
+
+	doc["$schema"] = JSONSchemaDraft07
+	wrapRef(doc)
+
+	// Definitions are needed for references. In de-reference mode, only cyclical references need them.
+	if !r.DeReference() || r.hasCycles {
+		definitions := map[string]interface{}{}
+		for _, refElem := range result.TypeRefs.Children {
+			// Nullability belongs to fields, not definitions.
+			definitions[refElem.Name] = r.schemaOf(refElem, false, false)
+		}
+		if len(definitions) > 0 {
+			doc["definitions"] = definitions
+		}
+	}
+
+	return doc, rendererErrors(r.analyze(result), r, r.isAnyInterface).err()
+}
+
+// schemaOf builds the JSON Schema for an element.
+// - If deref is true, TypeRefs are replaced with their types except for cyclical references.
+// - If nullable is true, nullable elements also allow null.
+func (r *JSONSchemaRenderer) schemaOf(t *types.TypeElement, deref bool, nullable bool) map[string]interface{} {
+	if r.isAnyInterface(t) {
+		out := map[string]interface{}{}
+		if t.Description != "" {
+			out["description"] = t.Description
+		}
+		return out
+	}
+
+	out := r.typeSchemaOf(t, deref)
+
+	if nullable && t.Nullable && t.Type != generictype.Interface.String() {
+		out = nullSchemaOf(out)
+	}
+
+	if t.Description != "" {
+		out["description"] = t.Description
+	}
+
+	if t.Error != "" && t.Error != types.CyclicalReferenceErr {
+		// Invalid elements allow any value with the error as a comment.
+		out["$comment"] = "error: " + t.Error
+	}
+
+	wrapRef(out)
+	return out
+}
+
+// wrapRef moves a "$ref" with sibling keywords into "allOf" because keywords next to "$ref" are ignored in draft-07.
+func wrapRef(out map[string]interface{}) {
+	if ref, ok := out["$ref"]; ok && len(out) > 1 {
+		delete(out, "$ref")
+		out["allOf"] = []interface{}{map[string]interface{}{"$ref": ref}}
+	}
+}
+
+// typeSchemaOf builds the JSON Schema for the type of an element.
+func (r *JSONSchemaRenderer) typeSchemaOf(t *types.TypeElement, deref bool) map[string]interface{} {
+	out := map[string]interface{}{}
+
+	jsonType := t.GetNativeType(r.opt.dialect())
+	if jsonType.TypeRef != "" {
+		if !deref {
+			out["$ref"] = "#/definitions/" + jsonType.TypeRef
+			return out
+		} else if t.Error == types.CyclicalReferenceErr {
+			r.hasCycles = true
+			out["$ref"] = "#/definitions/" + jsonType.TypeRef
+			return out
+		}
+	}
+
+	nativeType := t.NativeDefault()
+
+	switch t.SchemaType() {
+	case generictype.Struct.String():
+		out["type"] = "object"
+
+		if isMap(t) {
+			valueSchema := r.schemaOf(t.Children[0], deref, true)
+
+			if r.PropertyNames {
+				if propertyNames := propertyNamesOf(t); propertyNames != nil {
+					out["propertyNames"] = propertyNames
+				}
+				out["additionalProperties"] = valueSchema
+				break
+			}
+
+			// Keys that do not match the key pattern are not allowed.
+			if keyPattern, ok := nativeType.Options.Get("KeyPattern"); ok {
+				out["patternProperties"] = map[string]interface{}{keyPattern: valueSchema}
+				out["additionalProperties"] = false
+			} else {
+				out["additionalProperties"] = valueSchema
+			}
+			break
+		}
+
+		properties := map[string]interface{}{}
+		required := []string{}
+
+		for _, childElem := range t.Children {
+			childJSON := childElem.GetNativeType(r.opt.dialect())
+			if childJSON.Include == threeflag.False || skipElement(childElem, r) {
+				continue
+			}
+
+			// Catch-all fields are the schema of properties that are not known fields.
+			if isCatchAll(childElem, r.opt.dialect()) {
+				if isMap(childElem) {
+					out["additionalProperties"] = r.schemaOf(childElem.Children[0], deref, true)
+				} else {
+					out["additionalProperties"] = true
+				}
+				continue
+			}
+
+			properties[childJSON.Name] = r.schemaOf(childElem, deref, true)
+			if isRequired(childElem, r.opt.dialect()) {
+				required = append(required, childJSON.Name)
+			}
+		}
+
+		if len(properties) > 0 {
+			out["properties"] = properties
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			out["required"] = required
+		}
+	case generictype.List.String():
+		out["type"] = "array"
+		if len(t.Children) > 0 {
+			out["items"] = r.schemaOf(t.Children[0], deref, true)
+		}
+
+		// Go arrays have a fixed length. Slices are not bounded.
+		if nativeType.Type == "array" {
+			if arrayLen, err := strconv.Atoi(optionOf(nativeType, "Len")); err == nil {
+				out["minItems"] = arrayLen
+				out["maxItems"] = arrayLen
+			}
+		}
+	case generictype.Boolean.String():
+		out["type"] = "boolean"
+	case generictype.Integer.String():
+		out["type"] = "integer"
+		if isUnsigned(t) {
+			out["minimum"] = 0
+		}
+	case generictype.Float.String():
+		out["type"] = "number"
+	case generictype.String.String():
+		out["type"] = "string"
+		if format, ok := nativeType.Options.Get("Format"); ok {
+			out["format"] = format
+		}
+	case generictype.DateTime.String():
+		out["type"] = "string"
+		out["format"] = "date-time"
+	case generictype.Interface.String():
+		if isUnion(t) {
+			oneOf := []interface{}{}
+			for _, childElem := range t.Children {
+				oneOf = append(oneOf, r.schemaOf(childElem, deref, false))
+			}
+			out["oneOf"] = oneOf
+		}
+	}
+
+	// Enum and default values of basic types.
+	if t.TypeCategory == typecategory.Basic.String() {
+		if enumValues := t.EnumValues(); len(enumValues) > 0 {
+			values := []interface{}{}
+			for _, enumVal := range enumValues {
+				values = append(values, jsonValue(t, enumVal))
+			}
+			out["enum"] = values
+		}
+		if defaultVal, ok := nativeType.Options.Get("Default"); ok {
+			out["default"] = jsonValue(t, defaultVal)
+		}
+
+		// Constraint values are validated by the reflector so numbers can be used as they are.
+		for _, key := range types.ConstraintKeys {
+			if val, ok := t.Constraint(key); ok {
+				if key == "pattern" {
+					out[key] = val
+				} else {
+					out[key] = json.Number(val)
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// propertyNamesOf returns the schema of allowed keys of a map or nil if keys are not restricted.
+// - Key enums take precedence over key patterns.
+func propertyNamesOf(t *types.TypeElement) map[string]interface{} {
+	if keyValues := t.KeyEnumValues(); len(keyValues) > 0 {
+		return map[string]interface{}{"enum": keyValues}
+	}
+	if keyPattern, ok := t.NativeDefault().Options.Get("KeyPattern"); ok {
+		return map[string]interface{}{"pattern": keyPattern}
+	}
+	return nil
+}
+
+// nullSchemaOf returns a schema that allows null in addition to the given schema.
+// - Schemas with a single type add "null" to the type. Other schemas (e.g. $ref) are wrapped in anyOf.
+func nullSchemaOf(schema map[string]interface{}) map[string]interface{} {
+	if typeName, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typeName, "null"}
+		return schema
+	}
+
+	return map[string]interface{}{
+		"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}},
+	}
+}
+
+// jsonValue returns a JSON value for a string value of an element.
+// - Values that cannot be converted to the type of the element are returned as strings.
+func jsonValue(t *types.TypeElement, val string) interface{} {
+	switch t.Type {
+	case generictype.Boolean.String():
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	case generictype.Integer.String(), generictype.Float.String():
+		if _, err := strconv.ParseFloat(val, 64); err == nil {
+			return json.Number(val)
+		}
+	}
+	return val
+}
+
+// optionOf returns an option of a native type or "" if it is not set.
+func optionOf(nativeType *types.NativeType, key string) string {
+	val, _ := nativeType.Options.Get(key)
+	return val
+}
+
+// isAnyInterface returns true if an element is a nil interface rendered as an empty schema.
+func (r *JSONSchemaRenderer) isAnyInterface(t *types.TypeElement) bool {
+	return r.AnyForNilInterface && t.Error == types.NilInterfaceErr
+}
+
+func (r *JSONSchemaRenderer) DeReference() bool {
+	return r.opt.DeReference
+}
+
+func (r *JSONSchemaRenderer) OmitErrors() bool {
+	return r.opt.OmitErrors
+}
+
+func (r *JSONSchemaRenderer) TypeRenderer(typeName string) TypeRendererFunc {
+	return r.opt.TypeRenderers[typeName]
+}
+
+func (r *JSONSchemaRenderer) Indent() int {
+	return r.opt.Indent
+}
+
+func (r *JSONSchemaRenderer) SetIndent(value int) {
+	r.opt.Indent = value
+}
+
+func (r *JSONSchemaRenderer) Prefix() string {
+	if r.opt.Prefix == "" {
+		return ""
+	}
+	return strings.Repeat(r.opt.Prefix, r.opt.Indent)
+}
+
+// Pre, Post, and Path are not used because the JSON Schema document is built as a whole.
+func (r *JSONSchemaRenderer) Pre(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *JSONSchemaRenderer) Post(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *JSONSchemaRenderer) Path(t *types.TypeElement) []string {
+	return []string{}
+}
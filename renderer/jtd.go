@@ -0,0 +1,218 @@
+package renderer
+
+import (
+	"encoding/json"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"strings"
+)
+
+// JTDRenderer renders a JSON Typedef (RFC 8927) schema.
+type JTDRenderer struct {
+	sharedAnalysis
+
+	opt *Options
+
+	// hasCycles is set if a cyclical reference was rendered as a ref in de-reference mode.
+	hasCycles bool
+}
+
+func NewJTDRenderer(opt *Options) *JTDRenderer {
+	if opt == nil {
+		opt = NewOptions()
+	}
+
+	return &JTDRenderer{opt: opt}
+}
+
+func (r *JTDRenderer) ProcessResult(result *types.Schema) ([]string, error) {
+	doc, err := r.ProcessDocument(result)
+
+	b, marshalErr := json.MarshalIndent(doc, r.Prefix(), "  ")
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	return strings.Split(string(b), "\n"), err
+}
+
+// ProcessDocument returns the JTD schema as a document model that can be merged or encoded by the caller.
+func (r *JTDRenderer) ProcessDocument(result *types.Schema) (map[string]interface{}, error) {
+	r.hasCycles = false
+
+	// Root schema is the first child of the root element.
+	// - Root is not nullable even if it was reflected from a pointer.
+	doc := map[string]interface{}{}
+	if len(result.Root.Children) > 0 {
+		doc = r.schemaOf(result.Root.Children[0], r.DeReference())
+		delete(doc, "nullable")
+	}
+
+	// Definitions are needed for references. In de-reference mode, only cyclical references need them.
+	if !r.DeReference() || r.hasCycles {
+		definitions := map[string]interface{}{}
+		for _, refElem := range result.TypeRefs.Children {
+			// TypeRef children are always references. Nullability belongs to fields, not definitions.
+			definition := r.schemaOf(refElem, false)
+			delete(definition, "nullable")
+			definitions[refElem.Name] = definition
+		}
+		if len(definitions) > 0 {
+			doc["definitions"] = definitions
+		}
+	}
+
+	return doc, rendererErrors(r.analyze(result), r, nil).err()
+}
+
+// schemaOf builds the JTD schema form for an element.
+// - If deref is true, TypeRefs are replaced with their types except for cyclical references.
+func (r *JTDRenderer) schemaOf(t *types.TypeElement, deref bool) map[string]interface{} {
+	out := map[string]interface{}{}
+
+	if t.Nullable {
+		out["nullable"] = true
+	}
+
+	if t.Error != "" && t.Error != types.CyclicalReferenceErr {
+		// Invalid elements use the empty form with the error as metadata.
+		addMetadata(out, "error", t.Error)
+	}
+
+	jsonType := t.GetNativeType(r.opt.dialect())
+	if jsonType.TypeRef != "" {
+		if !deref {
+			out["ref"] = jsonType.TypeRef
+			return out
+		} else if t.Error == types.CyclicalReferenceErr {
+			r.hasCycles = true
+			out["ref"] = jsonType.TypeRef
+			return out
+		}
+	}
+
+	switch t.SchemaType() {
+	case generictype.Struct.String():
+		if isMap(t) {
+			out["values"] = r.schemaOf(t.Children[0], deref)
+			break
+		}
+
+		properties := map[string]interface{}{}
+		optionalProperties := map[string]interface{}{}
+
+		for _, childElem := range t.Children {
+			childJSON := childElem.GetNativeType(r.opt.dialect())
+			if childJSON.Include == threeflag.False || skipElement(childElem, r) {
+				continue
+			}
+
+			// JTD has no schema for additional properties, only a flag to allow them.
+			if isCatchAll(childElem, r.opt.dialect()) {
+				out["additionalProperties"] = true
+				continue
+			}
+
+			if hasOmitEmpty(childElem, r.opt.dialect()) {
+				optionalProperties[childJSON.Name] = r.schemaOf(childElem, deref)
+			} else {
+				properties[childJSON.Name] = r.schemaOf(childElem, deref)
+			}
+		}
+
+		// Properties form requires at least one of the properties keys.
+		if len(properties) > 0 || len(optionalProperties) == 0 {
+			out["properties"] = properties
+		}
+		if len(optionalProperties) > 0 {
+			out["optionalProperties"] = optionalProperties
+		}
+	case generictype.List.String():
+		if len(t.Children) > 0 {
+			out["elements"] = r.schemaOf(t.Children[0], deref)
+		}
+	case generictype.Boolean.String():
+		out["type"] = "boolean"
+	case generictype.Integer.String():
+		kind := t.NativeDefault().Type
+		out["type"] = jtdIntegerType(kind)
+		if out["type"] != kind {
+			// Values outside of the int32 range fail validation, so the narrowing is noted in the schema.
+			addMetadata(out, "note", kind+" is rendered as int32 because JTD has no 64-bit integer type")
+		}
+	case generictype.Float.String():
+		if t.NativeDefault().Type == "float32" {
+			out["type"] = "float32"
+		} else {
+			out["type"] = "float64"
+		}
+	case generictype.String.String():
+		out["type"] = "string"
+	case generictype.DateTime.String():
+		out["type"] = "timestamp"
+	}
+
+	return out
+}
+
+// addMetadata adds a key to the metadata of a schema form.
+func addMetadata(out map[string]interface{}, key string, val interface{}) {
+	metadata, ok := out["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		out["metadata"] = metadata
+	}
+	metadata[key] = val
+}
+
+// jtdIntegerType returns the JTD type for a Go integer kind.
+// - JTD only has integer types up to 32 bits. Larger integers use int32.
+func jtdIntegerType(kind string) string {
+	switch kind {
+	case "int8", "uint8", "int16", "uint16", "int32", "uint32":
+		return kind
+	default:
+		return "int32"
+	}
+}
+
+func (r *JTDRenderer) DeReference() bool {
+	return r.opt.DeReference
+}
+
+func (r *JTDRenderer) OmitErrors() bool {
+	return r.opt.OmitErrors
+}
+
+func (r *JTDRenderer) TypeRenderer(typeName string) TypeRendererFunc {
+	return r.opt.TypeRenderers[typeName]
+}
+
+func (r *JTDRenderer) Indent() int {
+	return r.opt.Indent
+}
+
+func (r *JTDRenderer) SetIndent(value int) {
+	r.opt.Indent = value
+}
+
+func (r *JTDRenderer) Prefix() string {
+	if r.opt.Prefix == "" {
+		return ""
+	}
+	return strings.Repeat(r.opt.Prefix, r.opt.Indent)
+}
+
+// Pre, Post, and Path are not used because the JTD document is built as a whole.
+func (r *JTDRenderer) Pre(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *JTDRenderer) Post(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *JTDRenderer) Path(t *types.TypeElement) []string {
+	return []string{}
+}
@@ -0,0 +1,206 @@
+package renderer
+
+import (
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"github.com/gitmann/b9schema-reflector-golang/lib/util"
+	"strings"
+)
+
+// MarkdownRenderer renders Markdown documentation with a table of fields for each struct type.
+// - Each struct TypeRef is a "### Name" section. A root type that is not a TypeRef is named after the root element, or "Root".
+// - Fields use their JSON names and are listed in alphabetical order. Struct types link to their section.
+// - An Error column is added to tables with errors.
+// - Markdown has no comments so the banner is ignored.
+type MarkdownRenderer struct {
+	sharedAnalysis
+
+	opt *Options
+
+	// typeRefs maps TypeRef names to their elements while a Schema is rendered.
+	typeRefs map[string]*types.TypeElement
+}
+
+func NewMarkdownRenderer(opt *Options) *MarkdownRenderer {
+	if opt == nil {
+		opt = NewOptions()
+	}
+
+	return &MarkdownRenderer{opt: opt}
+}
+
+func (r *MarkdownRenderer) ProcessResult(result *types.Schema) ([]string, error) {
+	r.typeRefs = r.analyze(result).typeRefs
+
+	out := []string{}
+	for _, name := range result.TypeRefs.ChildKeys(r.typeRefs) {
+		refElem := r.typeRefs[name]
+		if refElem.Type == generictype.Struct.String() && !isMap(refElem) {
+			out = append(out, r.sectionLines(refElem.Name, refElem)...)
+		}
+	}
+
+	// Root types without a TypeRef have no section yet.
+	if len(result.Root.Children) > 0 {
+		rootElem := result.Root.Children[0]
+		if rootElem.TypeRef == "" && rootElem.Type == generictype.Struct.String() && !isMap(rootElem) {
+			name := "Root"
+			if rootElem.Name != "" {
+				name = util.Capitalize(rootElem.Name)
+			}
+			out = append(out, r.sectionLines(name, rootElem)...)
+		}
+	}
+
+	// Sections are separated by blank lines. The last section has no trailing blank line.
+	if len(out) > 0 {
+		out = out[:len(out)-1]
+	}
+
+	return out, rendererErrors(r.analyze(result), r, nil).err()
+}
+
+// sectionLines returns a heading and a field table for a struct element, followed by a blank line.
+func (r *MarkdownRenderer) sectionLines(name string, t *types.TypeElement) []string {
+	childMap := t.ChildMap()
+
+	fields := []*types.TypeElement{}
+	hasErrors := false
+	for _, childName := range t.ChildKeys(childMap) {
+		childElem := childMap[childName]
+		if childElem.GetNativeType(r.opt.dialect()).Include == threeflag.False || skipElement(childElem, r) {
+			continue
+		}
+		fields = append(fields, childElem)
+		if childElem.Error != "" && childElem.Error != types.CyclicalReferenceErr {
+			hasErrors = true
+		}
+	}
+
+	out := []string{r.Prefix() + "### " + name, ""}
+
+	if hasErrors {
+		out = append(out,
+			r.Prefix()+"| Field | Type | Nullable | Description | Error |",
+			r.Prefix()+"| --- | --- | --- | --- | --- |",
+		)
+	} else {
+		out = append(out,
+			r.Prefix()+"| Field | Type | Nullable | Description |",
+			r.Prefix()+"| --- | --- | --- | --- |",
+		)
+	}
+
+	for _, childElem := range fields {
+		nullable := "no"
+		if childElem.Nullable {
+			nullable = "yes"
+		}
+
+		cells := []string{
+			markdownCell(childElem.GetNativeType(r.opt.dialect()).Name),
+			r.typeName(childElem),
+			nullable,
+			markdownCell(childElem.Description),
+		}
+		if hasErrors {
+			errorText := ""
+			if childElem.Error != types.CyclicalReferenceErr {
+				errorText = childElem.Error
+			}
+			cells = append(cells, markdownCell(errorText))
+		}
+
+		out = append(out, r.Prefix()+"| "+strings.Join(cells, " | ")+" |")
+	}
+
+	return append(out, "")
+}
+
+// typeName returns the type of an element for a table cell.
+// - Struct TypeRefs are links to their section. Lists are "Type[]" and maps are "map[string]Type".
+func (r *MarkdownRenderer) typeName(t *types.TypeElement) string {
+	if refElem := r.typeRefs[t.TypeRef]; t.TypeRef != "" && refElem != nil {
+		if refElem.Type == generictype.Struct.String() && !isMap(refElem) {
+			return "[" + t.TypeRef + "](#" + strings.ToLower(t.TypeRef) + ")"
+		}
+
+		// Named lists and maps are described by their TypeRefs.
+		if len(t.Children) == 0 {
+			t = refElem
+		}
+	}
+
+	switch t.SchemaType() {
+	case generictype.Struct.String():
+		if isMap(t) {
+			return "map[string]" + r.typeName(t.Children[0])
+		}
+		return "object"
+	case generictype.List.String():
+		if len(t.Children) == 0 {
+			return "any[]"
+		}
+		return r.typeName(t.Children[0]) + "[]"
+	case generictype.Interface.String():
+		if isUnion(t) {
+			names := []string{}
+			for _, childElem := range t.Children {
+				names = append(names, r.typeName(childElem))
+			}
+			return strings.Join(names, ` \| `)
+		}
+		return "any"
+	}
+
+	if format, ok := t.NativeDefault().Options.Get("Format"); ok {
+		return t.SchemaType() + " (" + format + ")"
+	}
+	return t.SchemaType()
+}
+
+// markdownCell escapes a value for a table cell. Pipes are escaped and line breaks are folded into spaces.
+func markdownCell(value string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(value), " "), "|", `\|`)
+}
+
+func (r *MarkdownRenderer) DeReference() bool {
+	return r.opt.DeReference
+}
+
+func (r *MarkdownRenderer) OmitErrors() bool {
+	return r.opt.OmitErrors
+}
+
+func (r *MarkdownRenderer) TypeRenderer(typeName string) TypeRendererFunc {
+	return r.opt.TypeRenderers[typeName]
+}
+
+func (r *MarkdownRenderer) Indent() int {
+	return r.opt.Indent
+}
+
+func (r *MarkdownRenderer) SetIndent(value int) {
+	r.opt.Indent = value
+}
+
+func (r *MarkdownRenderer) Prefix() string {
+	if r.opt.Prefix == "" {
+		return ""
+	}
+	return strings.Repeat(r.opt.Prefix, r.opt.Indent)
+}
+
+// Pre, Post, and Path are not used because sections are built as a whole.
+func (r *MarkdownRenderer) Pre(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *MarkdownRenderer) Post(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *MarkdownRenderer) Path(t *types.TypeElement) []string {
+	return []string{}
+}
@@ -4,15 +4,89 @@ import (
 	"fmt"
 	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
 	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/typecategory"
 	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+const (
+	// QueryParamTypeErr is set for root struct fields that cannot be rendered as query parameters.
+	QueryParamTypeErr = "query parameter must be a basic type"
+)
+
+// Nullable keywords for OpenAPIRenderer.NullableKeyword.
+const (
+	// NullableOpenAPI3 is the OpenAPI 3.0 keyword.
+	NullableOpenAPI3 = "nullable"
+
+	// NullableSwagger2 is the vendor extension used by Swagger 2.0 tools which have no nullable keyword.
+	NullableSwagger2 = "x-nullable"
+)
+
+// OpenAPI versions for OpenAPIRenderer.OpenAPIVersion.
+const (
+	// OpenAPIVersion30 renders nullability with NullableKeyword. This is the default.
+	OpenAPIVersion30 = "3.0"
+
+	// OpenAPIVersion31 renders nullability with JSON Schema type arrays, e.g. `type: [string, "null"]`.
+	OpenAPIVersion31 = "3.1"
+)
+
 // OpenAPIRenderer provides a simple string renderer.
 type OpenAPIRenderer struct {
+	sharedAnalysis
+
 	// Path
 	URLPath string
 
+	// Method is the HTTP method of the path operation. Default is "get".
+	Method string
+
+	// RequestBody renders the root schema as a required request body instead of a response schema.
+	// - Use with a method that has a body, e.g. "post" or "put".
+	// - Not used with QueryParams.
+	RequestBody bool
+
+	// EmitExampleRequest appends a commented example request with a JSON body after the path definition.
+	// - Not used with QueryParams.
+	EmitExampleRequest bool
+
+	// EmitInternalExtension adds "x-internal: true" to fields with the json "internal" option so doc tools can hide them.
+	EmitInternalExtension bool
+
+	// ExcludeInternal removes fields with the json "internal" option from the output.
+	ExcludeInternal bool
+
+	// AnyForNilInterface renders nil interfaces, which have no type, as an untyped schema that allows any value.
+	// - Default is to render them with an error. Use this for interface{} fields that hold arbitrary JSON.
+	AnyForNilInterface bool
+
+	// EmitFieldOrder adds an "x-order" extension with the Go field index to struct properties.
+	EmitFieldOrder bool
+
+	// EmitGoName adds an "x-go-name" extension with the Go field name to struct properties whose name differs.
+	EmitGoName bool
+
+	// OpenAPIVersion is the target OpenAPI version: OpenAPIVersion30 or OpenAPIVersion31. Default is OpenAPIVersion30.
+	// - With OpenAPIVersion31, nullable elements are always rendered with "null" in a type array and NullableKeyword is not used.
+	// - Nullable references are rendered as "anyOf" a $ref and a null type because siblings of $ref do not change its type.
+	OpenAPIVersion string
+
+	// NullableKeyword adds "<keyword>: true" to nullable elements (pointers, interfaces). Default is to omit nullability.
+	// - TypeRefs and the root schema are never nullable.
+	NullableKeyword string
+
+	// QueryParams renders fields of the root struct as query parameters instead of a response schema.
+	// - Only basic and known types are supported. Other types are rendered with an error.
+	QueryParams bool
+
+	// responses holds schemas for status codes other than 200. Set by AddResponse.
+	responses map[int]*types.Schema
+
 	opt *Options
 }
 
@@ -29,23 +103,293 @@ func NewOpenAPIRenderer(urlPath string, opt *Options) *OpenAPIRenderer {
 	}
 }
 
+// AddResponse adds a response schema for an HTTP status code, e.g. an error envelope for 404.
+// - The schema passed to ProcessResult is always the 200 response.
+// - TypeRefs of response schemas are added to the document. A TypeRef name that is already used keeps the first type.
+// - Not used with QueryParams.
+func (r *OpenAPIRenderer) AddResponse(status int, schema *types.Schema) {
+	if r.responses == nil {
+		r.responses = map[int]*types.Schema{}
+	}
+	r.responses[status] = schema
+}
+
 func (r *OpenAPIRenderer) ProcessResult(result *types.Schema) ([]string, error) {
-	out := []string{}
+	doc, errs := r.document(result)
+
+	b, err := encodeYAML(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	out := appendStrings(bannerLines(r.opt, "#"), strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"))
+
+	if !r.QueryParams && r.EmitExampleRequest && len(result.Root.Children) > 0 {
+		lines, err := r.exampleRequest(result)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		out = appendStrings(out, lines)
+	}
+
+	return out, errs.err()
+}
 
-	// Header
-	out = append(out, `openapi: 3.0.0`)
+// ProcessDocument returns the OpenAPI document as a document model that can be merged or encoded by the caller.
+// - The model is decoded from the same YAML nodes as ProcessResult. Comments are dropped.
+func (r *OpenAPIRenderer) ProcessDocument(result *types.Schema) (map[string]interface{}, error) {
+	doc, errs := r.document(result)
 
-	out = appendStrings(out, RenderSchema(result, r))
+	out := map[string]interface{}{}
+	if err := doc.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, errs.err()
+}
+
+// document builds the OpenAPI document as an ordered YAML mapping and returns it with the element errors.
+func (r *OpenAPIRenderer) document(result *types.Schema) (*yaml.Node, ElementErrors) {
+	analysis := r.analyze(result)
+	errs := rendererErrors(analysis, r, r.isAnyInterface)
+	cycles := analysis.cycles
+
+	if !r.QueryParams && len(r.responses) > 0 {
+		result = result.Clone()
+		for _, status := range r.responseStatuses() {
+			response := r.responses[status]
+			responseAnalysis := analyzeSchema(response)
+			errs = append(errs, rendererErrors(responseAnalysis, r, r.isAnyInterface)...)
+			cycles = cycles || responseAnalysis.cycles
+
+			for _, refElem := range response.TypeRefs.Children {
+				if result.TypeRefs.ChildByName(refElem.Name, nil) == nil {
+					result.TypeRefs.AddChild(refElem.Copy())
+				}
+			}
+		}
+	}
 
-	// Footer
+	doc := yamlMap()
+	yamlAdd(doc, "openapi", yamlString(r.version()))
+
+	if len(result.TypeRefs.Children) > 0 {
+		// Cyclical references are kept as references in de-reference mode so their TypeRefs are needed.
+		if !r.DeReference() || (!r.QueryParams && cycles) {
+			yamlAdd(doc, "components", r.componentsNode(result.TypeRefs))
+		}
+	}
+
+	if r.QueryParams {
+		paths, paramErrs := r.queryParamsNode(result.Root)
+		yamlAdd(doc, "paths", paths)
+		errs = append(errs, paramErrs...)
+	} else if len(result.Root.Children) > 0 {
+		yamlAdd(doc, "paths", r.pathsNode(result.Root))
+	}
+
+	return doc, errs
+}
+
+// componentsNode returns the "components" block with TypeRefs under the "schemas" key.
+// - TypeRefs are never de-referenced so that they can be the targets of references.
+func (r *OpenAPIRenderer) componentsNode(typeRefs *types.TypeElement) *yaml.Node {
+	schemas := yamlMap()
+
+	typeRefMap := typeRefs.ChildMap()
+	for _, name := range typeRefs.ChildKeys(typeRefMap) {
+		r.addElement(schemas, typeRefMap[name], false)
+	}
+
+	components := yamlMap()
+	yamlAdd(components, "schemas", schemas)
+	return components
+}
+
+// pathsNode returns the "paths" block with an operation for the root schema.
+// - The root schema is the 200 response or, with RequestBody, the request body.
+func (r *OpenAPIRenderer) pathsNode(root *types.TypeElement) *yaml.Node {
+	operation := yamlMap()
+
+	if r.RequestBody {
+		yamlAdd(operation, "summary", yamlString("Accept data."))
+		r.addOperationID(operation, root)
+
+		requestBody := yamlMap()
+		yamlAdd(requestBody, "required", yamlScalar("true"))
+		yamlAdd(requestBody, "content", r.contentNode(root))
+		yamlAdd(operation, "requestBody", requestBody)
+
+		success := yamlMap()
+		yamlAdd(success, "description", yamlString("Success"))
+		yamlAdd(operation, "responses", r.responsesNode(success))
+	} else {
+		yamlAdd(operation, "summary", yamlString("Return data."))
+		r.addOperationID(operation, root)
+
+		success := yamlMap()
+		yamlAdd(success, "description", yamlString("Success"))
+		yamlAdd(success, "content", r.contentNode(root))
+		yamlAdd(operation, "responses", r.responsesNode(success))
+	}
+
+	pathItem := yamlMap()
+	yamlAdd(pathItem, r.method(), operation)
+
+	paths := yamlMap()
+	yamlAdd(paths, r.URLPath, pathItem)
+	return paths
+}
+
+// contentNode returns the "content" block with the JSON schema of a root element.
+func (r *OpenAPIRenderer) contentNode(root *types.TypeElement) *yaml.Node {
+	schema := yamlMap()
+	for _, rootElem := range root.Children {
+		r.addElement(schema, rootElem, r.DeReference())
+	}
+
+	mediaType := yamlMap()
+	yamlAdd(mediaType, "schema", schema)
+
+	content := yamlMap()
+	yamlAdd(content, "application/json", mediaType)
+	return content
+}
+
+// responsesNode returns the "responses" block with the 200 response and added responses.
+func (r *OpenAPIRenderer) responsesNode(success *yaml.Node) *yaml.Node {
+	responses := yamlMap()
+	yamlAdd(responses, "200", success)
+
+	for _, status := range r.responseStatuses() {
+		description := http.StatusText(status)
+		if description == "" {
+			description = "Response"
+		}
+
+		response := yamlMap()
+		yamlAdd(response, "description", yamlString(description))
+		yamlAdd(response, "content", r.contentNode(r.responses[status].Root))
+		yamlAdd(responses, strconv.Itoa(status), response)
+	}
+
+	return responses
+}
+
+// responseStatuses returns the status codes of added responses in order.
+func (r *OpenAPIRenderer) responseStatuses() []int {
+	statuses := make([]int, 0, len(r.responses))
+	for status := range r.responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	return statuses
+}
+
+// exampleRequest returns a commented example request for the path.
+func (r *OpenAPIRenderer) exampleRequest(result *types.Schema) ([]string, error) {
+	body, err := result.ExampleJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []string{
+		`# Example request:`,
+		`# ` + strings.ToUpper(r.method()) + ` ` + r.URLPath,
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		out = append(out, `# `+line)
+	}
 
 	return out, nil
 }
 
+// queryParamsNode returns the "paths" block with the fields of the root struct as query parameters.
+func (r *OpenAPIRenderer) queryParamsNode(root *types.TypeElement) (*yaml.Node, ElementErrors) {
+	errs := ElementErrors{}
+
+	operation := yamlMap()
+	yamlAdd(operation, "summary", yamlString("Return data."))
+	r.addOperationID(operation, root)
+
+	// The root element holds the fields of the root struct.
+	if len(root.Children) > 0 {
+		rootElem := root.Children[0]
+
+		if rootElem.Error != "" {
+			yamlAdd(operation, "error", yamlString(rootElem.Error))
+		} else if len(rootElem.Children) > 0 {
+			parameters := yamlSeq()
+
+			// Error paths use the de-referenced form to match SchemaErrors.
+			simpleOpt := NewOptions()
+			simpleOpt.DeReference = true
+			simple := NewSimpleRenderer(simpleOpt)
+
+			typeRefMap := rootElem.ChildMap()
+			for _, childName := range rootElem.ChildKeys(typeRefMap) {
+				childElem := typeRefMap[childName]
+
+				jsonType := childElem.GetNativeType(r.opt.dialect())
+				if jsonType.Include == threeflag.False {
+					continue
+				}
+
+				parameter := yamlMap()
+				yamlAdd(parameter, "in", yamlString("query"))
+				yamlAdd(parameter, "name", yamlString(jsonType.Name))
+				parameters.Content = append(parameters.Content, parameter)
+
+				category := childElem.TypeCategory
+				if category != typecategory.Basic.String() && category != typecategory.Known.String() {
+					yamlAdd(parameter, "error", yamlString(QueryParamTypeErr))
+					errs = append(errs, fmt.Sprintf("%s ERROR:%s", strings.Join(simple.Path(childElem), "."), QueryParamTypeErr))
+					continue
+				}
+
+				if isRequired(childElem, r.opt.dialect()) {
+					yamlAdd(parameter, "required", yamlScalar("true"))
+				}
+
+				schema := yamlMap()
+				r.addSchema(schema, childElem, r.DeReference())
+				wrapRefNode(schema)
+				yamlAdd(parameter, "schema", schema)
+
+				if childElem.Error != "" {
+					yamlAdd(parameter, "error", yamlString(childElem.Error))
+				}
+			}
+
+			yamlAdd(operation, "parameters", parameters)
+		}
+	}
+
+	success := yamlMap()
+	yamlAdd(success, "description", yamlString("Success"))
+	responses := yamlMap()
+	yamlAdd(responses, "200", success)
+	yamlAdd(operation, "responses", responses)
+
+	pathItem := yamlMap()
+	yamlAdd(pathItem, r.method(), operation)
+
+	paths := yamlMap()
+	yamlAdd(paths, r.URLPath, pathItem)
+	return paths, errs
+}
+
 func (r *OpenAPIRenderer) DeReference() bool {
 	return r.opt.DeReference
 }
 
+func (r *OpenAPIRenderer) OmitErrors() bool {
+	return r.opt.OmitErrors
+}
+
+func (r *OpenAPIRenderer) TypeRenderer(typeName string) TypeRendererFunc {
+	return r.opt.TypeRenderers[typeName]
+}
+
 func (r *OpenAPIRenderer) Indent() int {
 	return r.opt.Indent
 }
@@ -61,132 +405,400 @@ func (r *OpenAPIRenderer) Prefix() string {
 	return strings.Repeat(r.opt.Prefix, r.opt.Indent)
 }
 
+// Pre returns the YAML of an element and its children for RenderType and RenderSchema.
+// - The TypeRefs element is rendered as the "components" block and the Root element as the "paths" block.
+// - Other elements are rendered as they appear in the document, e.g. a property under its name.
 func (r *OpenAPIRenderer) Pre(t *types.TypeElement) []string {
-	jsonType := t.GetNativeType("json")
-	if jsonType.Include == threeflag.False {
-		// Skip this element.
+	out := yamlMap()
+
+	switch {
+	case t.Type == generictype.Root.String() && t.Name == "TypeRefs":
+		if len(t.Children) > 0 {
+			yamlAdd(out, "components", r.componentsNode(t))
+		}
+	case t.Type == generictype.Root.String():
+		if r.QueryParams {
+			paths, _ := r.queryParamsNode(t)
+			yamlAdd(out, "paths", paths)
+		} else if len(t.Children) > 0 {
+			yamlAdd(out, "paths", r.pathsNode(t))
+		}
+	default:
+		r.addElement(out, t, r.DeReference())
+	}
+
+	if len(out.Content) == 0 {
 		return []string{}
 	}
 
-	// Special handling for root elements.
-	if t.Type == generictype.Root.String() {
-		r.SetIndent(r.Indent() + 1)
+	b, err := encodeYAML(out)
+	if err != nil {
+		return []string{r.Prefix() + "# ERROR:" + err.Error()}
+	}
 
-		if t.Name == "Root" {
-			// Build an API path.
-			out := []string{`paths:`}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	for i := range lines {
+		lines[i] = r.Prefix() + lines[i]
+	}
+	return lines
+}
 
-			out = append(out, r.Prefix()+r.URLPath)
+// Post and Path are not used because Pre renders the children of an element.
+func (r *OpenAPIRenderer) Post(t *types.TypeElement) []string {
+	return []string{}
+}
 
-			r.SetIndent(r.Indent() + 1)
-			out = append(out, r.Prefix()+`get:`)
+func (r *OpenAPIRenderer) Path(t *types.TypeElement) []string {
+	return []string{}
+}
 
-			r.SetIndent(r.Indent() + 1)
-			out = append(out, r.Prefix()+`summary: Return data.`)
-			out = append(out, r.Prefix()+`responses:`)
+// rendersChildren returns true because Pre renders the children of an element.
+func (r *OpenAPIRenderer) rendersChildren() bool {
+	return true
+}
 
-			r.SetIndent(r.Indent() + 1)
-			out = append(out, r.Prefix()+`'200':`)
+// addElement adds the schema of an element to a mapping.
+// - Named elements are added under their JSON name, e.g. properties and TypeRefs.
+// - The schema of unnamed elements (the root type, list items, map values) is merged into the mapping.
+// - Custom render functions from Options.RegisterTypeRenderer return YAML lines which are parsed and merged into the mapping.
+// - deref is true if TypeRefs are de-referenced, see isRef.
+func (r *OpenAPIRenderer) addElement(m *yaml.Node, t *types.TypeElement, deref bool) {
+	if skipElement(t, r) {
+		return
+	}
 
-			r.SetIndent(r.Indent() + 1)
-			out = append(out, r.Prefix()+`description: Success`)
-			out = append(out, r.Prefix()+`content:`)
+	if fn := typeRendererOf(t, r); fn != nil {
+		indent := r.Indent()
+		r.SetIndent(0)
+		lines := fn(t, r)
+		r.SetIndent(indent)
 
-			r.SetIndent(r.Indent() + 1)
-			out = append(out, r.Prefix()+`application/json:`)
+		custom := &yaml.Node{}
+		if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), custom); err != nil || len(custom.Content) == 0 || custom.Content[0].Kind != yaml.MappingNode {
+			yamlAdd(m, "error", yamlString(fmt.Sprintf("custom renderer for %s is not a YAML mapping", t.Name)))
+			return
+		}
+		m.Content = append(m.Content, custom.Content[0].Content...)
+		return
+	}
 
-			r.SetIndent(r.Indent() + 1)
-			out = append(out, r.Prefix()+`schema:`)
+	jsonType := t.GetNativeType(r.opt.dialect())
+	if jsonType.Include == threeflag.False {
+		return
+	}
 
-			r.SetIndent(r.Indent() + 1)
-			return out
-		} else if t.Name == "TypeRefs" {
-			// Store TypeRefs under the "components/schemas" key.
-			r.SetIndent(r.Indent() + 1)
-			return []string{
-				`components:`,
-				`  schemas:`,
-			}
+	schema := r.elementNode(t, deref)
+	if r.hasNameLine(t) {
+		yamlAdd(m, jsonType.Name, schema)
+	} else {
+		m.Content = append(m.Content, schema.Content...)
+	}
+}
+
+// elementNode returns the schema of an element with its extensions, description, and error.
+func (r *OpenAPIRenderer) elementNode(t *types.TypeElement, deref bool) *yaml.Node {
+	jsonType := t.GetNativeType(r.opt.dialect())
+
+	out := yamlMap()
+
+	if r.EmitFieldOrder && t.Parent != nil && t.Parent.Type == generictype.Struct.String() {
+		if fieldIndex, ok := t.NativeDefault().Options.Get("FieldIndex"); ok {
+			yamlAdd(out, "x-order", yamlScalar(fieldIndex))
+		}
+	}
+
+	if r.EmitGoName && t.Name != "" && t.Parent != nil && t.Parent.Type == generictype.Struct.String() {
+		// Struct fields are named by their Go field name in the native dialect. Map values are unnamed.
+		if goName := t.GetNativeType(t.NativeDialect).Name; goName != jsonType.Name {
+			yamlAdd(out, "x-go-name", yamlString(goName))
 		}
 	}
 
+	if r.EmitInternalExtension && isInternal(t, r.opt.dialect()) {
+		yamlAdd(out, "x-internal", yamlScalar("true"))
+	}
+
+	if t.Description != "" {
+		yamlAdd(out, "description", yamlString(t.Description))
+	}
+
+	r.addSchema(out, t, deref)
+
+	if t.Error != "" && !(r.opt.CycleAsRef && t.Error == types.CyclicalReferenceErr) && !r.isAnyInterface(t) {
+		yamlAdd(out, "error", yamlString(t.Error))
+	}
+
+	wrapRefNode(out)
+	return out
+}
+
+// wrapRefNode moves a "$ref" with sibling keys into "allOf" because keys next to "$ref" are ignored in OpenAPI 3.0.
+func wrapRefNode(out *yaml.Node) {
+	if len(out.Content) <= 2 {
+		return
+	}
+
+	for i := 0; i < len(out.Content); i += 2 {
+		if out.Content[i].Value == "$ref" {
+			ref := yamlMap()
+			ref.Content = append(ref.Content, out.Content[i], out.Content[i+1])
+			out.Content[i] = yamlString("allOf")
+			out.Content[i+1] = yamlSeq(ref)
+			return
+		}
+	}
+}
+
+// addSchema adds the schema keys of an element to a mapping.
+// - Struct, list, and union elements include the schemas of their children.
+func (r *OpenAPIRenderer) addSchema(out *yaml.Node, t *types.TypeElement, deref bool) {
+	jsonType := t.GetNativeType(r.opt.dialect())
 	nativeType := t.NativeDefault()
 
-	outLines := []string{}
+	if r.NullableKeyword != "" && r.OpenAPIVersion != OpenAPIVersion31 && r.isNullable(t) {
+		yamlAdd(out, r.NullableKeyword, yamlScalar("true"))
+	}
 
-	if jsonType.Name != "" {
-		outLines = append(outLines, fmt.Sprintf("%s%s:", r.Prefix(), jsonType.Name))
-		r.SetIndent(r.Indent() + 1)
+	if r.isAnyInterface(t) {
+		r.addAny(out)
+		return
 	}
 
-	if jsonType.TypeRef != "" {
-		outLines = append(outLines, fmt.Sprintf(`%s$ref: '#/definitions/%s'`, r.Prefix(), jsonType.TypeRef))
+	if r.isRef(t, deref) {
+		ref := yamlMap()
+		yamlAdd(ref, "$ref", yamlString(schemaRef(jsonType.TypeRef)))
+
+		if r.OpenAPIVersion == OpenAPIVersion31 && r.isNullable(t) {
+			null := yamlMap()
+			yamlAdd(null, "type", yamlString("null"))
+			yamlAdd(out, "anyOf", yamlSeq(ref, null))
+		} else {
+			out.Content = append(out.Content, ref.Content...)
+		}
 	} else {
-		switch t.Type {
+		switch t.SchemaType() {
 		case generictype.Struct.String():
-			outLines = append(outLines,
-				r.Prefix()+"type: object",
-				r.Prefix()+"properties:",
-			)
-			r.SetIndent(r.Indent() + 1)
+			yamlAdd(out, "type", r.typeNode(t, "object"))
+
+			if isMap(t) {
+				values := yamlMap()
+				r.addElement(values, t.Children[0], deref)
+				yamlAdd(out, "additionalProperties", values)
+				break
+			}
+
+			properties := yamlMap()
+			childMap := t.ChildMap()
+			for _, childName := range t.ChildKeys(childMap) {
+				r.addElement(properties, childMap[childName], deref)
+			}
+			if len(properties.Content) > 0 {
+				yamlAdd(out, "properties", properties)
+			}
+
+			r.addRequired(out, t)
+
+			if catchAll := catchAllOf(t, r.opt.dialect()); catchAll != nil {
+				if isMap(catchAll) {
+					values := yamlMap()
+					r.addElement(values, catchAll.Children[0], deref)
+					yamlAdd(out, "additionalProperties", values)
+				} else {
+					// Untyped values allow any properties.
+					yamlAdd(out, "additionalProperties", yamlScalar("true"))
+				}
+			}
 		case generictype.List.String():
-			outLines = append(outLines,
-				r.Prefix()+"type: array",
-				r.Prefix()+"items:",
-			)
-			r.SetIndent(r.Indent() + 1)
+			yamlAdd(out, "type", r.typeNode(t, "array"))
+
+			// Go arrays have a fixed length. Slices are not bounded.
+			if nativeType.Type == "array" {
+				if arrayLen, ok := nativeType.Options.Get("Len"); ok {
+					yamlAdd(out, "minItems", yamlScalar(arrayLen))
+					yamlAdd(out, "maxItems", yamlScalar(arrayLen))
+				}
+			}
+
+			items := yamlMap()
+			for _, childElem := range t.Children {
+				r.addElement(items, childElem, deref)
+			}
+			yamlAdd(out, "items", items)
 		case generictype.Boolean.String():
-			outLines = append(outLines,
-				r.Prefix()+"type: boolean",
-			)
+			yamlAdd(out, "type", r.typeNode(t, "boolean"))
 		case generictype.Integer.String():
-			outLines = append(outLines,
-				r.Prefix()+"type: integer",
-			)
-			if nativeType.Type == "int64" || nativeType.Type == "uint64" {
-				outLines = append(outLines,
-					r.Prefix()+"format: int64",
-				)
+			yamlAdd(out, "type", r.typeNode(t, "integer"))
+			switch nativeType.Type {
+			case "int32", "uint32":
+				yamlAdd(out, "format", yamlString("int32"))
+			case "int64", "uint64":
+				yamlAdd(out, "format", yamlString("int64"))
+			}
+			if _, ok := t.Constraint("minimum"); isUnsigned(t) && !ok {
+				yamlAdd(out, "minimum", yamlScalar("0"))
 			}
 		case generictype.Float.String():
-			outLines = append(outLines,
-				r.Prefix()+"type: number",
-			)
-			if nativeType.Type == "float64" {
-				outLines = append(outLines,
-					r.Prefix()+"format: double",
-				)
+			yamlAdd(out, "type", r.typeNode(t, "number"))
+			switch nativeType.Type {
+			case "float32":
+				yamlAdd(out, "format", yamlString("float"))
+			case "float64":
+				yamlAdd(out, "format", yamlString("double"))
 			}
 		case generictype.String.String():
-			outLines = append(outLines,
-				r.Prefix()+"type: string",
-			)
+			yamlAdd(out, "type", r.typeNode(t, "string"))
+			if format, ok := nativeType.Options.Get("Format"); ok {
+				yamlAdd(out, "format", yamlString(format))
+			}
 		case generictype.DateTime.String():
-			outLines = append(outLines,
-				r.Prefix()+"type: string",
-				r.Prefix()+"format: date-time",
-			)
+			yamlAdd(out, "type", r.typeNode(t, "string"))
+			yamlAdd(out, "format", yamlString("date-time"))
+		case generictype.Interface.String():
+			if isUnion(t) {
+				oneOf := yamlSeq()
+				for _, childElem := range t.Children {
+					item := yamlMap()
+					r.addElement(item, childElem, deref)
+					if len(item.Content) > 0 {
+						oneOf.Content = append(oneOf.Content, item)
+					}
+				}
+				yamlAdd(out, "oneOf", oneOf)
+				break
+			}
+			r.addAny(out)
 		default:
-			outLines = append(outLines,
-				r.Prefix()+"type: "+t.Type,
-			)
+			yamlAdd(out, "type", r.typeNode(t, t.Type))
 		}
 	}
 
-	if t.Error != "" {
-		outLines = append(outLines,
-			r.Prefix()+"error: "+t.Error,
-		)
+	// Enum and default values of basic types. Enums of TypeRefs are rendered with the TypeRef.
+	if t.TypeCategory == typecategory.Basic.String() {
+		if jsonType.TypeRef == "" {
+			if enumValues := t.EnumValues(); len(enumValues) > 0 {
+				enum := yamlSeq()
+				for _, enumVal := range enumValues {
+					enum.Content = append(enum.Content, yamlValue(t, enumVal))
+				}
+				yamlAdd(out, "enum", enum)
+			}
+		}
+
+		if defaultVal, ok := nativeType.Options.Get("Default"); ok {
+			yamlAdd(out, "default", yamlValue(t, defaultVal))
+		}
+
+		for _, key := range types.ConstraintKeys {
+			if val, ok := t.Constraint(key); ok {
+				if key == "pattern" {
+					yamlAdd(out, key, yamlQuoted(val))
+				} else {
+					yamlAdd(out, key, yamlScalar(val))
+				}
+			}
+		}
 	}
+}
 
-	return outLines
+// addOperationID adds an operationId if the root type is named.
+func (r *OpenAPIRenderer) addOperationID(operation *yaml.Node, root *types.TypeElement) {
+	if len(root.Children) > 0 && root.Children[0].Name != "" {
+		yamlAdd(operation, "operationId", yamlString(root.Children[0].Name))
+	}
 }
 
-func (r *OpenAPIRenderer) Post(t *types.TypeElement) []string {
-	return []string{}
+// method returns the HTTP method of the path operation.
+func (r *OpenAPIRenderer) method() string {
+	if r.Method == "" {
+		return "get"
+	}
+	return strings.ToLower(r.Method)
 }
 
-// Path is a function that builds a path string from a TypeElement.
-func (r *OpenAPIRenderer) Path(t *types.TypeElement) []string {
-	return []string{}
+// schemaRef returns the reference to a TypeRef in the components/schemas block.
+func schemaRef(typeRef string) string {
+	return "#/components/schemas/" + typeRef
+}
+
+// version returns the OpenAPI version of the header.
+func (r *OpenAPIRenderer) version() string {
+	if r.OpenAPIVersion == OpenAPIVersion31 {
+		return "3.1.0"
+	}
+	return "3.0.0"
+}
+
+// isNullable returns true if nullability is rendered for an element.
+// - Interfaces are untyped and always allow null. The root schema is never nullable.
+func (r *OpenAPIRenderer) isNullable(t *types.TypeElement) bool {
+	return t.Nullable && t.Type != generictype.Interface.String() &&
+		t.Parent != nil && t.Parent.Type != generictype.Root.String()
+}
+
+// typeNode returns the type of an element. Nullable elements of OpenAPI 3.1 have a type array with "null".
+func (r *OpenAPIRenderer) typeNode(t *types.TypeElement, typeName string) *yaml.Node {
+	if r.OpenAPIVersion == OpenAPIVersion31 && r.isNullable(t) {
+		return yamlFlowSeq(typeName, "null")
+	}
+	return yamlString(typeName)
+}
+
+// addAny adds the keys of an untyped schema which allows any value.
+// - OpenAPI 3.0 uses the empty schema "{}". Elements that are otherwise empty are rendered as an empty mapping.
+// - OpenAPI 3.1 has no nullable keyword so all types are listed.
+func (r *OpenAPIRenderer) addAny(out *yaml.Node) {
+	if r.OpenAPIVersion == OpenAPIVersion31 {
+		yamlAdd(out, "type", yamlFlowSeq("string", "number", "integer", "boolean", "object", "array", "null"))
+	}
+}
+
+// addRequired adds the "required" list of an object with the names of its required properties.
+// - Properties are required if they are not nullable and have no omitempty option, see isRequired.
+func (r *OpenAPIRenderer) addRequired(out *yaml.Node, t *types.TypeElement) {
+	required := []string{}
+	for _, childElem := range t.Children {
+		childJSON := childElem.GetNativeType(r.opt.dialect())
+		if childJSON.Include == threeflag.False || skipElement(childElem, r) {
+			continue
+		}
+		if isRequired(childElem, r.opt.dialect()) {
+			required = append(required, childJSON.Name)
+		}
+	}
+
+	if len(required) == 0 {
+		return
+	}
+	sort.Strings(required)
+
+	names := yamlSeq()
+	for _, name := range required {
+		names.Content = append(names.Content, yamlString(name))
+	}
+	yamlAdd(out, "required", names)
+}
+
+// Skip returns true for elements that are not rendered as properties:
+// - catch-all fields, which are rendered as additionalProperties of their parent
+// - internal fields if ExcludeInternal is set
+func (r *OpenAPIRenderer) Skip(t *types.TypeElement) bool {
+	return isCatchAll(t, r.opt.dialect()) || (r.ExcludeInternal && isInternal(t, r.opt.dialect()))
+}
+
+// hasNameLine returns true if an element is added to its parent under its name.
+// - The name of the root type is the operationId, not a property.
+func (r *OpenAPIRenderer) hasNameLine(t *types.TypeElement) bool {
+	isRootType := t.Parent != nil && t.Parent.Type == generictype.Root.String() && t.Parent.Name == "Root"
+	return t.GetNativeType(r.opt.dialect()).Name != "" && !isRootType
+}
+
+// isAnyInterface returns true if an element is a nil interface rendered as an untyped schema.
+func (r *OpenAPIRenderer) isAnyInterface(t *types.TypeElement) bool {
+	return r.AnyForNilInterface && t.Error == types.NilInterfaceErr
+}
+
+// isRef returns true if an element is rendered as a reference.
+// - If deref is true, only cyclical references are kept as references.
+func (r *OpenAPIRenderer) isRef(t *types.TypeElement, deref bool) bool {
+	return t.GetNativeType(r.opt.dialect()).TypeRef != "" && (!deref || t.Error == types.CyclicalReferenceErr)
 }
@@ -5,14 +5,55 @@ type Options struct {
 	// - If TyepRefs have a cyclical relationship, the last TypeRef is kept as a TypeRef.
 	DeReference bool
 
+	// CycleAsRef renders cyclical references as plain references without an error in de-reference mode.
+	// - Document renderers (OpenAPI) use this to produce valid documents for recursive types.
+	CycleAsRef bool
+
+	// OmitErrors skips elements with errors and their children when rendering.
+	// - Cyclical references are kept because renderers render them as references.
+	OmitErrors bool
+
+	// Dialect is the struct tag dialect used for names and options, e.g. "mapstructure" for config structs. Default is "json".
+	Dialect string
+
+	// Banner is a header, e.g. "Code generated by b9schema. DO NOT EDIT.", added as comment lines before the output.
+	// - Each line is commented with the syntax of the output format. Formats without comments (JSON, JTD) ignore the banner.
+	Banner string
+
 	// Prefix is a string used as a prefix for indented lines.
 	Prefix string
 
 	// Indent is used for rendering where indent matters.
 	Indent int
+
+	// TypeRenderers are custom render functions by TypeRef name. Use RegisterTypeRenderer to set them.
+	TypeRenderers map[string]TypeRendererFunc
 }
 
 func NewOptions() *Options {
 	opt := &Options{}
 	return opt
 }
+
+// dialect returns the struct tag dialect for names and options.
+func (opt *Options) dialect() string {
+	if opt.Dialect == "" {
+		return "json"
+	}
+	return opt.Dialect
+}
+
+// RegisterTypeRenderer sets a custom render function for a TypeRef name. A nil fn removes the function.
+// - The function is used by RenderType for TypeRef definitions and for elements that reference the TypeRef.
+// - The function replaces Pre, Post, and child processing so it must render the element name if needed.
+func (opt *Options) RegisterTypeRenderer(typeName string, fn TypeRendererFunc) {
+	if fn == nil {
+		delete(opt.TypeRenderers, typeName)
+		return
+	}
+
+	if opt.TypeRenderers == nil {
+		opt.TypeRenderers = map[string]TypeRendererFunc{}
+	}
+	opt.TypeRenderers[typeName] = fn
+}
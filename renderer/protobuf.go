@@ -0,0 +1,317 @@
+package renderer
+
+import (
+	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/threeflag"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"github.com/gitmann/b9schema-reflector-golang/lib/util"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Protobuf field numbers assigned by hash are in [1, maxHashFieldNumber].
+// - Numbers 19000-19999 are reserved by protobuf so hashed numbers stay below them.
+const maxHashFieldNumber = 18999
+
+// ProtobufFieldNumbers returns field numbers for the children of a struct element, keyed by child name.
+// - Numbers from the "protobuf" struct tag are used as-is.
+// - Other fields are numbered by a hash of their name so numbers do not change when fields are added or reordered.
+// - Hash collisions are resolved by trying the next number, in order of field name.
+// - Returns an error if two fields have the same tagged number.
+func ProtobufFieldNumbers(t *types.TypeElement) (map[string]int, error) {
+	numbers := map[string]int{}
+	used := map[int]string{}
+
+	// Tagged numbers are reserved first.
+	untagged := []string{}
+	for _, childElem := range t.Children {
+		val, ok := childElem.NativeDefault().Options.Get("ProtobufField")
+		if !ok {
+			untagged = append(untagged, childElem.Name)
+			continue
+		}
+
+		number, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("field %q has invalid protobuf field number %q", childElem.Name, val)
+		}
+		if other, ok := used[number]; ok {
+			return nil, fmt.Errorf("fields %q and %q have the same protobuf field number %d", other, childElem.Name, number)
+		}
+
+		numbers[childElem.Name] = number
+		used[number] = childElem.Name
+	}
+
+	// Process untagged fields in a stable order so collisions resolve the same way every time.
+	sort.Strings(untagged)
+	for _, name := range untagged {
+		number := hashFieldNumber(name)
+		for used[number] != "" {
+			number = number%maxHashFieldNumber + 1
+		}
+
+		numbers[name] = number
+		used[number] = name
+	}
+
+	return numbers, nil
+}
+
+// hashFieldNumber returns a field number in [1, maxHashFieldNumber] from a hash of the field name.
+func hashFieldNumber(name string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int(h.Sum32()%maxHashFieldNumber) + 1
+}
+
+// protoTimestampImport is the import for google.protobuf.Timestamp.
+const protoTimestampImport = "google/protobuf/timestamp.proto"
+
+// ProtoRenderer renders proto3 messages for the struct types of a Schema.
+// - Each struct TypeRef is a message. Anonymous structs are nested messages named after their field, e.g. "Address" for "address".
+// - A root type that is not a TypeRef is a message named after the root element, or "Root".
+// - Fields use their JSON names and are listed in alphabetical order like other renderers order children.
+// - Fields are numbered by ProtobufFieldNumbers so numbers do not change when fields are added or reordered.
+// - Messages with duplicate "protobuf" tag numbers have no fields, only an error comment. The error is returned as an element error.
+// - Types that proto3 cannot express (interfaces, nested lists, errors) are "// unsupported" comments. They keep their field number.
+// - Messages are always referenced by name so DeReference does not apply.
+type ProtoRenderer struct {
+	sharedAnalysis
+
+	opt *Options
+
+	// typeRefs maps TypeRef names to their elements while a Schema is rendered.
+	typeRefs map[string]*types.TypeElement
+
+	// imports holds the imports needed by the rendered messages.
+	imports map[string]bool
+
+	// numberErrs holds field numbering errors of messages while a Schema is rendered.
+	numberErrs ElementErrors
+}
+
+func NewProtoRenderer(opt *Options) *ProtoRenderer {
+	if opt == nil {
+		opt = NewOptions()
+	}
+
+	return &ProtoRenderer{opt: opt}
+}
+
+func (r *ProtoRenderer) ProcessResult(result *types.Schema) ([]string, error) {
+	r.typeRefs = r.analyze(result).typeRefs
+	r.imports = map[string]bool{}
+	r.numberErrs = ElementErrors{}
+
+	messages := []string{}
+	for _, name := range result.TypeRefs.ChildKeys(r.typeRefs) {
+		refElem := r.typeRefs[name]
+		if refElem.Type == generictype.Struct.String() && !isMap(refElem) {
+			messages = append(messages, "")
+			messages = append(messages, r.messageLines(refElem.Name, refElem, 0)...)
+		}
+	}
+
+	// Root types without a TypeRef have no message yet.
+	if len(result.Root.Children) > 0 {
+		rootElem := result.Root.Children[0]
+		if rootElem.TypeRef == "" && rootElem.Type == generictype.Struct.String() && !isMap(rootElem) {
+			name := "Root"
+			if rootElem.Name != "" {
+				name = util.Capitalize(rootElem.Name)
+			}
+			messages = append(messages, "")
+			messages = append(messages, r.messageLines(name, rootElem, 0)...)
+		}
+	}
+
+	out := bannerLines(r.opt, "//")
+	out = append(out, r.Prefix()+`syntax = "proto3";`)
+
+	if len(r.imports) > 0 {
+		out = append(out, "")
+		importNames := []string{}
+		for name := range r.imports {
+			importNames = append(importNames, name)
+		}
+		sort.Strings(importNames)
+		for _, name := range importNames {
+			out = append(out, r.Prefix()+fmt.Sprintf("import %q;", name))
+		}
+	}
+
+	for _, line := range messages {
+		if line == "" {
+			out = append(out, line)
+		} else {
+			out = append(out, r.Prefix()+line)
+		}
+	}
+
+	return out, append(rendererErrors(r.analyze(result), r, nil), r.numberErrs...).err()
+}
+
+// messageLines returns the lines of a message for a struct element. depth is the nesting depth of the message.
+func (r *ProtoRenderer) messageLines(name string, t *types.TypeElement, depth int) []string {
+	indent := strings.Repeat("  ", depth)
+
+	fields := []*types.TypeElement{}
+	for _, childElem := range t.Children {
+		if childElem.GetNativeType(r.opt.dialect()).Include != threeflag.False && !(r.OmitErrors() && childElem.Error != "" && childElem.Error != types.CyclicalReferenceErr) {
+			fields = append(fields, childElem)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+
+	numbers, err := ProtobufFieldNumbers(t)
+	if err != nil {
+		// Changing a tagged number would break wire compatibility so the message has no fields until the tags are fixed.
+		simpleOpt := NewOptions()
+		simpleOpt.DeReference = true
+		r.numberErrs = append(r.numberErrs, fmt.Sprintf("%s ERROR:%s", strings.Join(NewSimpleRenderer(simpleOpt).Path(t), "."), err))
+
+		out := []string{fmt.Sprintf("%smessage %s {", indent, name)}
+		out = append(out, fmt.Sprintf("%s  // error: %s", indent, err))
+		return append(out, indent+"}")
+	}
+
+	nested := []string{}
+	fieldLines := []string{}
+	for _, childElem := range fields {
+		fieldName := childElem.GetNativeType(r.opt.dialect()).Name
+
+		// Anonymous structs are nested messages. Message names must differ from field names.
+		if childElem.Type == generictype.Struct.String() && childElem.TypeRef == "" && !isMap(childElem) && childElem.Error == "" {
+			nestedName := util.Capitalize(fieldName)
+			if nestedName == fieldName {
+				nestedName += "Message"
+			}
+			nested = append(nested, r.messageLines(nestedName, childElem, depth+1)...)
+			fieldLines = append(fieldLines, fmt.Sprintf("%s  %s %s = %d;", indent, nestedName, fieldName, numbers[childElem.Name]))
+			continue
+		}
+
+		fieldType, ok := r.fieldType(childElem)
+		if !ok || isCatchAll(childElem, r.opt.dialect()) {
+			fieldLines = append(fieldLines, fmt.Sprintf("%s  // unsupported: %s = %d;", indent, fieldName, numbers[childElem.Name]))
+			continue
+		}
+		fieldLines = append(fieldLines, fmt.Sprintf("%s  %s %s = %d;", indent, fieldType, fieldName, numbers[childElem.Name]))
+	}
+
+	out := []string{fmt.Sprintf("%smessage %s {", indent, name)}
+	out = append(out, nested...)
+	out = append(out, fieldLines...)
+	return append(out, indent+"}")
+}
+
+// fieldType returns the proto3 type of a field element and true, or false if proto3 cannot express the type.
+func (r *ProtoRenderer) fieldType(t *types.TypeElement) (string, bool) {
+	if t.Error != "" && t.Error != types.CyclicalReferenceErr {
+		return "", false
+	}
+
+	// Named lists and maps are resolved to their TypeRefs.
+	if t.TypeRef != "" && len(t.Children) == 0 && r.typeRefs[t.TypeRef] != nil {
+		if refElem := r.typeRefs[t.TypeRef]; refElem.Type == generictype.List.String() || isMap(refElem) {
+			t = refElem
+		}
+	}
+
+	nativeType := t.NativeDefault()
+
+	switch t.SchemaType() {
+	case generictype.Struct.String():
+		if !isMap(t) {
+			return t.TypeRef, t.TypeRef != ""
+		}
+		valueType, ok := r.fieldType(t.Children[0])
+		if !ok || strings.HasPrefix(valueType, "repeated ") || strings.HasPrefix(valueType, "map<") {
+			return "", false
+		}
+		return "map<string, " + valueType + ">", true
+	case generictype.List.String():
+		if len(t.Children) == 0 {
+			return "", false
+		}
+		itemType, ok := r.fieldType(t.Children[0])
+		if !ok || strings.HasPrefix(itemType, "repeated ") || strings.HasPrefix(itemType, "map<") {
+			return "", false
+		}
+		return "repeated " + itemType, true
+	case generictype.Boolean.String():
+		return "bool", true
+	case generictype.Integer.String():
+		switch nativeType.Type {
+		case "int8", "int16", "int32":
+			return "int32", true
+		case "uint8", "uint16", "uint32":
+			return "uint32", true
+		}
+		if isUnsigned(t) {
+			return "uint64", true
+		}
+		return "int64", true
+	case generictype.Float.String():
+		if nativeType.Type == "float32" {
+			return "float", true
+		}
+		return "double", true
+	case generictype.String.String():
+		if format, _ := nativeType.Options.Get("Format"); format == "byte" {
+			return "bytes", true
+		}
+		return "string", true
+	case generictype.DateTime.String():
+		r.imports[protoTimestampImport] = true
+		return "google.protobuf.Timestamp", true
+	}
+
+	return "", false
+}
+
+func (r *ProtoRenderer) DeReference() bool {
+	return r.opt.DeReference
+}
+
+func (r *ProtoRenderer) OmitErrors() bool {
+	return r.opt.OmitErrors
+}
+
+func (r *ProtoRenderer) TypeRenderer(typeName string) TypeRendererFunc {
+	return r.opt.TypeRenderers[typeName]
+}
+
+func (r *ProtoRenderer) Indent() int {
+	return r.opt.Indent
+}
+
+func (r *ProtoRenderer) SetIndent(value int) {
+	r.opt.Indent = value
+}
+
+func (r *ProtoRenderer) Prefix() string {
+	if r.opt.Prefix == "" {
+		return ""
+	}
+	return strings.Repeat(r.opt.Prefix, r.opt.Indent)
+}
+
+// Pre, Post, and Path are not used because messages are built as a whole.
+func (r *ProtoRenderer) Pre(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *ProtoRenderer) Post(t *types.TypeElement) []string {
+	return []string{}
+}
+
+func (r *ProtoRenderer) Path(t *types.TypeElement) []string {
+	return []string{}
+}
@@ -0,0 +1,57 @@
+package renderer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RendererFunc builds a Renderer with the given options.
+type RendererFunc func(opt *Options) Renderer
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]RendererFunc{
+		"go":         func(opt *Options) Renderer { return NewGoRenderer(opt) },
+		"json":       func(opt *Options) Renderer { return NewJSONRenderer(opt) },
+		"jsonschema": func(opt *Options) Renderer { return NewJSONSchemaRenderer(opt) },
+		"jtd":        func(opt *Options) Renderer { return NewJTDRenderer(opt) },
+		"markdown":   func(opt *Options) Renderer { return NewMarkdownRenderer(opt) },
+		// OpenAPI documents are rendered for the root path. Use NewOpenAPIRenderer to set a path and other fields.
+		"openapi": func(opt *Options) Renderer { return NewOpenAPIRenderer("/", opt) },
+		"proto":   func(opt *Options) Renderer { return NewProtoRenderer(opt) },
+		"simple":  func(opt *Options) Renderer { return NewSimpleRenderer(opt) },
+	}
+)
+
+// RegisterRenderer adds a named renderer for NewRenderer and b9schema.Render. A nil fn removes the renderer.
+func RegisterRenderer(name string, fn RendererFunc) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	if fn == nil {
+		delete(renderers, name)
+	} else {
+		renderers[name] = fn
+	}
+}
+
+// NewRenderer returns a new renderer by name. Names are: go, json, jsonschema, jtd, markdown, openapi, proto, simple and any registered names.
+func NewRenderer(name string, opt *Options) (Renderer, error) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	fn, ok := renderers[name]
+	if !ok {
+		names := make([]string, 0, len(renderers))
+		for k := range renderers {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("unknown renderer %q: must be one of %s", name, strings.Join(names, ", "))
+	}
+
+	return fn(opt), nil
+}
@@ -3,7 +3,10 @@ package renderer
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
 	"github.com/gitmann/b9schema-reflector-golang/reflector"
+	"gopkg.in/yaml.v3"
 	"reflect"
 	"strings"
 	"testing"
@@ -19,7 +22,13 @@ var allTests = [][]TestCase{
 	compoundTests,
 	referenceTests,
 	cycleTests,
+	valueCycleTests,
+	embeddedCycleTests,
+	nestedMapTests,
 	jsonTagTests,
+	structListTests,
+	mapValueTests,
+	namedEntityTests,
 
 	// structTests,
 	// pointerTests,
@@ -34,6 +43,7 @@ type TestCase struct {
 	derefStrings   []string
 	jsonStrings    []string
 	openapiStrings []string
+	jtdStrings     []string
 }
 
 // *** All reflect types ***
@@ -299,6 +309,7 @@ type CompoundTypes struct {
 // Special types from protobuf: https://developers.google.com/protocol-buffers/docs/reference/google.protobuf
 type SpecialTypes struct {
 	DateTime time.Time
+	Duration time.Duration
 }
 
 var typeTests = []TestCase{
@@ -323,17 +334,19 @@ var typeTests = []TestCase{
 			`      properties:`,
 			`        Bool:`,
 			`          type: boolean`,
+			`      required:`,
+			`        - Bool`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/BoolTypes'`,
+			`                $ref: '#/components/schemas/BoolTypes'`,
 		},
 	},
 	{
@@ -381,6 +394,7 @@ var typeTests = []TestCase{
 			`          type: integer`,
 			`        Int32:`,
 			`          type: integer`,
+			`          format: int32`,
 			`        Int64:`,
 			`          type: integer`,
 			`          format: int64`,
@@ -388,28 +402,47 @@ var typeTests = []TestCase{
 			`          type: integer`,
 			`        Uint:`,
 			`          type: integer`,
+			`          minimum: 0`,
 			`        Uint16:`,
 			`          type: integer`,
+			`          minimum: 0`,
 			`        Uint32:`,
 			`          type: integer`,
+			`          format: int32`,
+			`          minimum: 0`,
 			`        Uint64:`,
 			`          type: integer`,
 			`          format: int64`,
+			`          minimum: 0`,
 			`        Uint8:`,
 			`          type: integer`,
+			`          minimum: 0`,
 			`        Uintptr:`,
 			`          type: integer`,
+			`          minimum: 0`,
+			`      required:`,
+			`        - Int`,
+			`        - Int16`,
+			`        - Int32`,
+			`        - Int64`,
+			`        - Int8`,
+			`        - Uint`,
+			`        - Uint16`,
+			`        - Uint32`,
+			`        - Uint64`,
+			`        - Uint8`,
+			`        - Uintptr`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/IntegerTypes'`,
+			`                $ref: '#/components/schemas/IntegerTypes'`,
 		},
 	},
 	{
@@ -435,20 +468,24 @@ var typeTests = []TestCase{
 			`      properties:`,
 			`        Float32:`,
 			`          type: number`,
+			`          format: float`,
 			`        Float64:`,
 			`          type: number`,
 			`          format: double`,
+			`      required:`,
+			`        - Float32`,
+			`        - Float64`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/FloatTypes'`,
+			`                $ref: '#/components/schemas/FloatTypes'`,
 		},
 	},
 	{
@@ -472,17 +509,19 @@ var typeTests = []TestCase{
 			`      properties:`,
 			`        String:`,
 			`          type: string`,
+			`      required:`,
+			`        - String`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/StringTypes'`,
+			`                $ref: '#/components/schemas/StringTypes'`,
 		},
 	},
 	{
@@ -527,17 +566,23 @@ var typeTests = []TestCase{
 			`        UnsafePointer:`,
 			`          type: invalid:unsafe.Pointer`,
 			`          error: kind not supported`,
+			`      required:`,
+			`        - Chan`,
+			`        - Complex128`,
+			`        - Complex64`,
+			`        - Func`,
+			`        - UnsafePointer`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/InvalidTypes'`,
+			`                $ref: '#/components/schemas/InvalidTypes'`,
 		},
 	},
 	{
@@ -585,10 +630,14 @@ var typeTests = []TestCase{
 			`      properties:`,
 			`        Array0:`,
 			`          type: array`,
+			`          minItems: 0`,
+			`          maxItems: 0`,
 			`          items:`,
 			`            type: string`,
 			`        Array3:`,
 			`          type: array`,
+			`          minItems: 3`,
+			`          maxItems: 3`,
 			`          items:`,
 			`            type: string`,
 			`        Interface:`,
@@ -596,12 +645,11 @@ var typeTests = []TestCase{
 			`          error: interface element is nil`,
 			`        Map:`,
 			`          type: object`,
-			`          properties:`,
-			`            error: map key type must be string`,
+			`          error: map key type must be string`,
 			`        PrivatePtr:`,
-			`          $ref: '#/definitions/PrivateStruct'`,
+			`          $ref: '#/components/schemas/PrivateStruct'`,
 			`        Ptr:`,
-			`          $ref: '#/definitions/StringStruct'`,
+			`          $ref: '#/components/schemas/StringStruct'`,
 			`        Slice:`,
 			`          type: array`,
 			`          items:`,
@@ -609,28 +657,34 @@ var typeTests = []TestCase{
 			`            error: interface element is nil`,
 			`        Struct:`,
 			`          type: object`,
-			`          properties:`,
-			`            error: empty struct not supported`,
+			`          error: empty struct not supported`,
+			`      required:`,
+			`        - Array0`,
+			`        - Array3`,
+			`        - Map`,
+			`        - Slice`,
+			`        - Struct`,
 			`    PrivateStruct:`,
 			`      type: object`,
-			`      properties:`,
-			`        error: struct has no exported fields`,
+			`      error: struct has no exported fields`,
 			`    StringStruct:`,
 			`      type: object`,
 			`      properties:`,
 			`        Value:`,
 			`          type: string`,
+			`      required:`,
+			`        - Value`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/CompoundTypes'`,
+			`                $ref: '#/components/schemas/CompoundTypes'`,
 		},
 	},
 	{
@@ -639,11 +693,13 @@ var typeTests = []TestCase{
 		refStrings: []string{
 			`TypeRefs.SpecialTypes:{}`,
 			`TypeRefs.SpecialTypes:{}.DateTime:datetime`,
+			`TypeRefs.SpecialTypes:{}.Duration:duration`,
 			`Root.{}:SpecialTypes`,
 		},
 		derefStrings: []string{
 			`Root.{}`,
 			`Root.{}.DateTime:datetime`,
+			`Root.{}.Duration:duration`,
 		},
 		openapiStrings: []string{
 			`openapi: 3.0.0`,
@@ -655,17 +711,23 @@ var typeTests = []TestCase{
 			`        DateTime:`,
 			`          type: string`,
 			`          format: date-time`,
+			`        Duration:`,
+			`          type: string`,
+			`          format: duration`,
+			`      required:`,
+			`        - DateTime`,
+			`        - Duration`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/SpecialTypes'`,
+			`                $ref: '#/components/schemas/SpecialTypes'`,
 		},
 	},
 }
@@ -727,29 +789,41 @@ var listTests = []TestCase{
 			`      properties:`,
 			`        Array0:`,
 			`          type: array`,
+			`          minItems: 0`,
+			`          maxItems: 0`,
 			`          items:`,
 			`            type: string`,
 			`        Array2_3:`,
 			`          type: array`,
+			`          minItems: 2`,
+			`          maxItems: 2`,
 			`          items:`,
 			`            type: array`,
+			`            minItems: 3`,
+			`            maxItems: 3`,
 			`            items:`,
 			`              type: string`,
 			`        Array3:`,
 			`          type: array`,
+			`          minItems: 3`,
+			`          maxItems: 3`,
 			`          items:`,
 			`            type: string`,
+			`      required:`,
+			`        - Array0`,
+			`        - Array2_3`,
+			`        - Array3`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/ArrayStruct'`,
+			`                $ref: '#/components/schemas/ArrayStruct'`,
 		},
 	},
 	{
@@ -778,11 +852,11 @@ var listTests = []TestCase{
 		openapiStrings: []string{
 			`openapi: 3.0.0`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
@@ -844,17 +918,20 @@ var listTests = []TestCase{
 			`          type: array`,
 			`          items:`,
 			`            type: string`,
+			`      required:`,
+			`        - Array2`,
+			`        - Slice`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/SliceStruct'`,
+			`                $ref: '#/components/schemas/SliceStruct'`,
 		},
 	},
 }
@@ -944,6 +1021,7 @@ var compoundTests = []TestCase{
 			`              type: boolean`,
 			`            FloatVal:`,
 			`              type: number`,
+			`              format: float`,
 			`            IntVal:`,
 			`              type: number`,
 			`              format: double`,
@@ -965,19 +1043,34 @@ var compoundTests = []TestCase{
 			`                    DeepKey2:`,
 			`                      type: number`,
 			`                      format: double`,
+			`                  required:`,
+			`                    - DeepKey1`,
+			`                    - DeepKey2`,
+			`              required:`,
+			`                - Key1`,
+			`                - Key2`,
 			`            StringVal:`,
 			`              type: string`,
+			`          required:`,
+			`            - BoolVal`,
+			`            - FloatVal`,
+			`            - IntVal`,
+			`            - ListVal`,
+			`            - MapVal`,
+			`            - StringVal`,
+			`      required:`,
+			`        - MapOK`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/MapTestsStruct'`,
+			`                $ref: '#/components/schemas/MapTestsStruct'`,
 		},
 	},
 	{
@@ -1016,11 +1109,11 @@ var compoundTests = []TestCase{
 		openapiStrings: []string{
 			`openapi: 3.0.0`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
@@ -1148,26 +1241,31 @@ var referenceTests = []TestCase{
 			`          type: integer`,
 			`        StringVal:`,
 			`          type: string`,
+			`      required:`,
+			`        - BoolVal`,
+			`        - Float64Val`,
+			`        - IntVal`,
+			`        - StringVal`,
 			`    ReferenceTestsStruct:`,
 			`      type: object`,
 			`      properties:`,
 			`        InterfaceVal:`,
-			`          $ref: '#/definitions/BasicStruct'`,
+			`          $ref: '#/components/schemas/BasicStruct'`,
 			`        PtrPtrVal:`,
-			`          $ref: '#/definitions/BasicStruct'`,
+			`          $ref: '#/components/schemas/BasicStruct'`,
 			`        PtrVal:`,
-			`          $ref: '#/definitions/BasicStruct'`,
+			`          $ref: '#/components/schemas/BasicStruct'`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/ReferenceTestsStruct'`,
+			`                $ref: '#/components/schemas/ReferenceTestsStruct'`,
 		},
 	},
 }
@@ -1249,15 +1347,15 @@ var cycleTests = []TestCase{
 			`Root.{}.Level:integer`,
 		},
 		jsonStrings: []string{
-			`definitions.cycleA:{}`,
-			`definitions.cycleA:{}.aChild:{}:BStruct`,
-			`definitions.cycleA:{}.aName:string`,
-			`definitions.aChild:{}`,
-			`definitions.aChild:{}.bChild:{}:CStruct`,
-			`definitions.aChild:{}.bName:string`,
-			`definitions.bChild:{}`,
-			`definitions.bChild:{}.cChild:{}:AStruct`,
-			`definitions.bChild:{}.cName:string`,
+			`definitions.AStruct:{}`,
+			`definitions.AStruct:{}.aChild:{}:BStruct`,
+			`definitions.AStruct:{}.aName:string`,
+			`definitions.BStruct:{}`,
+			`definitions.BStruct:{}.bChild:{}:CStruct`,
+			`definitions.BStruct:{}.bName:string`,
+			`definitions.CStruct:{}`,
+			`definitions.CStruct:{}.cChild:{}:AStruct`,
+			`definitions.CStruct:{}.cName:string`,
 			`definitions.CycleTest:{}`,
 			`definitions.CycleTest:{}.cycleA:{}:AStruct`,
 			`definitions.CycleTest:{}.cycleB:{}:BStruct`,
@@ -1269,51 +1367,374 @@ var cycleTests = []TestCase{
 			`openapi: 3.0.0`,
 			`components:`,
 			`  schemas:`,
-			`    cycleA:`,
+			`    AStruct:`,
 			`      type: object`,
 			`      properties:`,
 			`        aChild:`,
-			`          $ref: '#/definitions/BStruct'`,
+			`          $ref: '#/components/schemas/BStruct'`,
 			`        aName:`,
 			`          type: string`,
-			`    aChild:`,
+			`    BStruct:`,
 			`      type: object`,
 			`      properties:`,
 			`        bChild:`,
-			`          $ref: '#/definitions/CStruct'`,
+			`          $ref: '#/components/schemas/CStruct'`,
 			`        bName:`,
 			`          type: string`,
-			`    bChild:`,
+			`      required:`,
+			`        - bName`,
+			`    CStruct:`,
 			`      type: object`,
 			`      properties:`,
 			`        cChild:`,
-			`          $ref: '#/definitions/AStruct'`,
+			`          $ref: '#/components/schemas/AStruct'`,
 			`        cName:`,
 			`          type: string`,
+			`      required:`,
+			`        - cName`,
 			`    CycleTest:`,
 			`      type: object`,
 			`      properties:`,
 			`        cycleA:`,
-			`          $ref: '#/definitions/AStruct'`,
+			`          $ref: '#/components/schemas/AStruct'`,
 			`        cycleB:`,
-			`          $ref: '#/definitions/BStruct'`,
+			`          $ref: '#/components/schemas/BStruct'`,
 			`        CycleC:`,
 			`          type: object`,
 			`          properties:`,
 			`            c:`,
-			`              $ref: '#/definitions/CStruct'`,
+			`              $ref: '#/components/schemas/CStruct'`,
+			`          required:`,
+			`            - c`,
+			`      required:`,
+			`        - CycleC`,
+			`        - cycleA`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/CycleTest'`,
+			`                $ref: '#/components/schemas/CycleTest'`,
+		},
+		jtdStrings: []string{
+			`{`,
+			`  "definitions": {`,
+			`    "AStruct": {`,
+			`      "optionalProperties": {`,
+			`        "aName": {`,
+			`          "type": "string"`,
+			`        }`,
+			`      },`,
+			`      "properties": {`,
+			`        "aChild": {`,
+			`          "nullable": true,`,
+			`          "ref": "BStruct"`,
+			`        }`,
+			`      }`,
+			`    },`,
+			`    "BStruct": {`,
+			`      "properties": {`,
+			`        "bChild": {`,
+			`          "nullable": true,`,
+			`          "ref": "CStruct"`,
+			`        },`,
+			`        "bName": {`,
+			`          "type": "string"`,
+			`        }`,
+			`      }`,
+			`    },`,
+			`    "CStruct": {`,
+			`      "properties": {`,
+			`        "cChild": {`,
+			`          "nullable": true,`,
+			`          "ref": "AStruct"`,
+			`        },`,
+			`        "cName": {`,
+			`          "type": "string"`,
+			`        }`,
+			`      }`,
+			`    },`,
+			`    "CycleTest": {`,
+			`      "properties": {`,
+			`        "CycleC": {`,
+			`          "properties": {`,
+			`            "c": {`,
+			`              "ref": "CStruct"`,
+			`            }`,
+			`          }`,
+			`        },`,
+			`        "cycleA": {`,
+			`          "ref": "AStruct"`,
+			`        },`,
+			`        "cycleB": {`,
+			`          "nullable": true,`,
+			`          "ref": "BStruct"`,
+			`        }`,
+			`      }`,
+			`    }`,
+			`  },`,
+			`  "ref": "CycleTest"`,
+			`}`,
+		},
+	},
+}
+
+// TreeStruct references itself by value through a slice and a map.
+type TreeStruct struct {
+	Name     string
+	Children []TreeStruct
+	Index    map[string]TreeStruct
+}
+
+// Empty and populated trees must produce the same schema.
+var treeRefStrings = []string{
+	`TypeRefs.TreeStruct:{}`,
+	`TypeRefs.TreeStruct:{}.Children:[]`,
+	`TypeRefs.TreeStruct:{}.Children:[].{}:TreeStruct`,
+	`TypeRefs.TreeStruct:{}.Index:{}`,
+	`TypeRefs.TreeStruct:{}.Index:{}.{}:TreeStruct`,
+	`TypeRefs.TreeStruct:{}.Name:string`,
+	`Root.{}:TreeStruct`,
+}
+
+var treeDerefStrings = []string{
+	`Root.{}`,
+	`Root.{}.Children:[]`,
+	`Root.{}.Children:[].!{}:TreeStruct! ERROR:cyclical reference`,
+	`Root.{}.Index:{}`,
+	`Root.{}.Index:{}.!{}:TreeStruct! ERROR:cyclical reference`,
+	`Root.{}.Name:string`,
+}
+
+var treeOpenAPIStrings = []string{
+	`openapi: 3.0.0`,
+	`components:`,
+	`  schemas:`,
+	`    TreeStruct:`,
+	`      type: object`,
+	`      properties:`,
+	`        Children:`,
+	`          type: array`,
+	`          items:`,
+	`            $ref: '#/components/schemas/TreeStruct'`,
+	`        Index:`,
+	`          type: object`,
+	`          additionalProperties:`,
+	`            $ref: '#/components/schemas/TreeStruct'`,
+	`        Name:`,
+	`          type: string`,
+	`      required:`,
+	`        - Children`,
+	`        - Index`,
+	`        - Name`,
+	`paths:`,
+	`  /test/path:`,
+	`    get:`,
+	`      summary: Return data.`,
+	`      responses:`,
+	`        "200":`,
+	`          description: Success`,
+	`          content:`,
+	`            application/json:`,
+	`              schema:`,
+	`                $ref: '#/components/schemas/TreeStruct'`,
+}
+
+var valueCycleTests = []TestCase{
+	{
+		name:           "value-cycle-empty",
+		value:          TreeStruct{},
+		refStrings:     treeRefStrings,
+		derefStrings:   treeDerefStrings,
+		openapiStrings: treeOpenAPIStrings,
+	},
+	{
+		name: "value-cycle-populated",
+		value: TreeStruct{
+			Children: []TreeStruct{{Name: "leaf", Children: []TreeStruct{{Name: "deep"}}}},
+			Index:    map[string]TreeStruct{"leaf": {Name: "leaf"}},
+		},
+		refStrings:     treeRefStrings,
+		derefStrings:   treeDerefStrings,
+		openapiStrings: treeOpenAPIStrings,
+	},
+}
+
+// EmbeddedNode embeds a pointer to itself.
+// - The embedded pointer is a cyclical reference so it is reflected as a field named after its type instead of being flattened.
+type EmbeddedNode struct {
+	*EmbeddedNode
+	Value string
+}
+
+var embeddedNodeOpenAPIStrings = []string{
+	`openapi: 3.0.0`,
+	`components:`,
+	`  schemas:`,
+	`    EmbeddedNode:`,
+	`      type: object`,
+	`      properties:`,
+	`        EmbeddedNode:`,
+	`          $ref: '#/components/schemas/EmbeddedNode'`,
+	`        Value:`,
+	`          type: string`,
+	`      required:`,
+	`        - Value`,
+	`paths:`,
+	`  /test/path:`,
+	`    get:`,
+	`      summary: Return data.`,
+	`      responses:`,
+	`        "200":`,
+	`          description: Success`,
+	`          content:`,
+	`            application/json:`,
+	`              schema:`,
+	`                $ref: '#/components/schemas/EmbeddedNode'`,
+}
+
+var embeddedCycleTests = []TestCase{
+	{
+		name:  "embedded-cycle-nil",
+		value: EmbeddedNode{},
+		refStrings: []string{
+			`TypeRefs.EmbeddedNode:{}`,
+			`TypeRefs.EmbeddedNode:{}.EmbeddedNode:{}:EmbeddedNode`,
+			`TypeRefs.EmbeddedNode:{}.Value:string`,
+			`Root.{}:EmbeddedNode`,
+		},
+		derefStrings: []string{
+			`Root.{}`,
+			`Root.{}.!EmbeddedNode:{}:EmbeddedNode! ERROR:cyclical reference`,
+			`Root.{}.Value:string`,
+		},
+		openapiStrings: embeddedNodeOpenAPIStrings,
+	},
+	{
+		name:  "embedded-cycle-populated",
+		value: EmbeddedNode{EmbeddedNode: &EmbeddedNode{Value: "parent"}},
+		refStrings: []string{
+			`TypeRefs.EmbeddedNode:{}`,
+			`TypeRefs.EmbeddedNode:{}.EmbeddedNode:{}:EmbeddedNode`,
+			`TypeRefs.EmbeddedNode:{}.Value:string`,
+			`Root.{}:EmbeddedNode`,
+		},
+		derefStrings: []string{
+			`Root.{}`,
+			`Root.{}.!EmbeddedNode:{}:EmbeddedNode! ERROR:cyclical reference`,
+			`Root.{}.Value:string`,
+		},
+		openapiStrings: embeddedNodeOpenAPIStrings,
+	},
+}
+
+// NestedMapStruct has maps of maps.
+type NestedMapStruct struct {
+	Counts   map[string]map[string]int64
+	Entities map[string]map[string]*GoodEntity
+}
+
+// Empty and populated maps must produce the same schema.
+var nestedMapRefStrings = []string{
+	`TypeRefs.GoodEntity:{}`,
+	`TypeRefs.GoodEntity:{}.IntVal:integer`,
+	`TypeRefs.GoodEntity:{}.Message:string`,
+	`TypeRefs.GoodEntity:{}.Same:boolean`,
+	`TypeRefs.NestedMapStruct:{}`,
+	`TypeRefs.NestedMapStruct:{}.Counts:{}`,
+	`TypeRefs.NestedMapStruct:{}.Counts:{}.{}`,
+	`TypeRefs.NestedMapStruct:{}.Counts:{}.{}.integer`,
+	`TypeRefs.NestedMapStruct:{}.Entities:{}`,
+	`TypeRefs.NestedMapStruct:{}.Entities:{}.{}`,
+	`TypeRefs.NestedMapStruct:{}.Entities:{}.{}.{}:GoodEntity`,
+	`Root.{}:NestedMapStruct`,
+}
+
+var nestedMapDerefStrings = []string{
+	`Root.{}`,
+	`Root.{}.Counts:{}`,
+	`Root.{}.Counts:{}.{}`,
+	`Root.{}.Counts:{}.{}.integer`,
+	`Root.{}.Entities:{}`,
+	`Root.{}.Entities:{}.{}`,
+	`Root.{}.Entities:{}.{}.{}`,
+	`Root.{}.Entities:{}.{}.{}.IntVal:integer`,
+	`Root.{}.Entities:{}.{}.{}.Message:string`,
+	`Root.{}.Entities:{}.{}.{}.Same:boolean`,
+}
+
+var nestedMapOpenAPIStrings = []string{
+	`openapi: 3.0.0`,
+	`components:`,
+	`  schemas:`,
+	`    GoodEntity:`,
+	`      type: object`,
+	`      properties:`,
+	`        IntVal:`,
+	`          type: integer`,
+	`          format: int64`,
+	`        Message:`,
+	`          type: string`,
+	`        Same:`,
+	`          type: boolean`,
+	`      required:`,
+	`        - IntVal`,
+	`        - Message`,
+	`        - Same`,
+	`    NestedMapStruct:`,
+	`      type: object`,
+	`      properties:`,
+	`        Counts:`,
+	`          type: object`,
+	`          additionalProperties:`,
+	`            type: object`,
+	`            additionalProperties:`,
+	`              type: integer`,
+	`              format: int64`,
+	`        Entities:`,
+	`          type: object`,
+	`          additionalProperties:`,
+	`            type: object`,
+	`            additionalProperties:`,
+	`              $ref: '#/components/schemas/GoodEntity'`,
+	`      required:`,
+	`        - Counts`,
+	`        - Entities`,
+	`paths:`,
+	`  /test/path:`,
+	`    get:`,
+	`      summary: Return data.`,
+	`      responses:`,
+	`        "200":`,
+	`          description: Success`,
+	`          content:`,
+	`            application/json:`,
+	`              schema:`,
+	`                $ref: '#/components/schemas/NestedMapStruct'`,
+}
+
+var nestedMapTests = []TestCase{
+	{
+		name:           "nested-map-empty",
+		value:          NestedMapStruct{},
+		refStrings:     nestedMapRefStrings,
+		derefStrings:   nestedMapDerefStrings,
+		openapiStrings: nestedMapOpenAPIStrings,
+	},
+	{
+		name: "nested-map-populated",
+		value: NestedMapStruct{
+			Counts:   map[string]map[string]int64{"a": {"b": 1}},
+			Entities: map[string]map[string]*GoodEntity{"a": {"b": &GoodEntity{Message: "hello"}}},
 		},
+		refStrings:     nestedMapRefStrings,
+		derefStrings:   nestedMapDerefStrings,
+		openapiStrings: nestedMapOpenAPIStrings,
 	},
 }
 
@@ -1363,18 +1784,512 @@ var jsonTagTests = []TestCase{
 			`          type: string`,
 			`        something:`,
 			`          type: string`,
+			`      required:`,
+			`        - NoTag`,
+			`        - renameOne`,
+			`        - something`,
 			`paths:`,
-			`  /test/path`,
+			`  /test/path:`,
 			`    get:`,
 			`      summary: Return data.`,
 			`      responses:`,
-			`        '200':`,
+			`        "200":`,
 			`          description: Success`,
 			`          content:`,
 			`            application/json:`,
 			`              schema:`,
-			`                $ref: '#/definitions/JSONTagTests'`,
+			`                $ref: '#/components/schemas/JSONTagTests'`,
+		},
+	},
+}
+
+// StructListStruct has lists of named structs.
+type StructListStruct struct {
+	Array3 [3]StringStruct
+	Slice  []GoodEntity
+}
+
+// Empty and populated lists of structs must produce the same schema.
+var structListRefStrings = []string{
+	`TypeRefs.GoodEntity:{}`,
+	`TypeRefs.GoodEntity:{}.IntVal:integer`,
+	`TypeRefs.GoodEntity:{}.Message:string`,
+	`TypeRefs.GoodEntity:{}.Same:boolean`,
+	`TypeRefs.StringStruct:{}`,
+	`TypeRefs.StringStruct:{}.Value:string`,
+	`TypeRefs.StructListStruct:{}`,
+	`TypeRefs.StructListStruct:{}.Array3:[]`,
+	`TypeRefs.StructListStruct:{}.Array3:[].{}:StringStruct`,
+	`TypeRefs.StructListStruct:{}.Slice:[]`,
+	`TypeRefs.StructListStruct:{}.Slice:[].{}:GoodEntity`,
+	`Root.{}:StructListStruct`,
+}
+
+var structListDerefStrings = []string{
+	`Root.{}`,
+	`Root.{}.Array3:[]`,
+	`Root.{}.Array3:[].{}`,
+	`Root.{}.Array3:[].{}.Value:string`,
+	`Root.{}.Slice:[]`,
+	`Root.{}.Slice:[].{}`,
+	`Root.{}.Slice:[].{}.IntVal:integer`,
+	`Root.{}.Slice:[].{}.Message:string`,
+	`Root.{}.Slice:[].{}.Same:boolean`,
+}
+
+var structListOpenAPIStrings = []string{
+	`openapi: 3.0.0`,
+	`components:`,
+	`  schemas:`,
+	`    GoodEntity:`,
+	`      type: object`,
+	`      properties:`,
+	`        IntVal:`,
+	`          type: integer`,
+	`          format: int64`,
+	`        Message:`,
+	`          type: string`,
+	`        Same:`,
+	`          type: boolean`,
+	`      required:`,
+	`        - IntVal`,
+	`        - Message`,
+	`        - Same`,
+	`    StringStruct:`,
+	`      type: object`,
+	`      properties:`,
+	`        Value:`,
+	`          type: string`,
+	`      required:`,
+	`        - Value`,
+	`    StructListStruct:`,
+	`      type: object`,
+	`      properties:`,
+	`        Array3:`,
+	`          type: array`,
+	`          minItems: 3`,
+	`          maxItems: 3`,
+	`          items:`,
+	`            $ref: '#/components/schemas/StringStruct'`,
+	`        Slice:`,
+	`          type: array`,
+	`          items:`,
+	`            $ref: '#/components/schemas/GoodEntity'`,
+	`      required:`,
+	`        - Array3`,
+	`        - Slice`,
+	`paths:`,
+	`  /test/path:`,
+	`    get:`,
+	`      summary: Return data.`,
+	`      responses:`,
+	`        "200":`,
+	`          description: Success`,
+	`          content:`,
+	`            application/json:`,
+	`              schema:`,
+	`                $ref: '#/components/schemas/StructListStruct'`,
+}
+
+var structListTests = []TestCase{
+	{
+		name:           "struct-list-empty",
+		value:          StructListStruct{},
+		refStrings:     structListRefStrings,
+		derefStrings:   structListDerefStrings,
+		openapiStrings: structListOpenAPIStrings,
+	},
+	{
+		name: "struct-list-populated",
+		value: StructListStruct{
+			Slice: []GoodEntity{{Message: "hello"}, {IntVal: 123}},
+		},
+		refStrings:     structListRefStrings,
+		derefStrings:   structListDerefStrings,
+		openapiStrings: structListOpenAPIStrings,
+	},
+}
+
+// MapValueStruct has maps with concrete value types.
+type MapValueStruct struct {
+	EntityMap    map[string]GoodEntity
+	EntityPtrMap map[string]*GoodEntity
+	IntMap       map[string]int64
+}
+
+// Empty and populated maps must produce the same schema.
+var mapValueRefStrings = []string{
+	`TypeRefs.GoodEntity:{}`,
+	`TypeRefs.GoodEntity:{}.IntVal:integer`,
+	`TypeRefs.GoodEntity:{}.Message:string`,
+	`TypeRefs.GoodEntity:{}.Same:boolean`,
+	`TypeRefs.MapValueStruct:{}`,
+	`TypeRefs.MapValueStruct:{}.EntityMap:{}`,
+	`TypeRefs.MapValueStruct:{}.EntityMap:{}.{}:GoodEntity`,
+	`TypeRefs.MapValueStruct:{}.EntityPtrMap:{}`,
+	`TypeRefs.MapValueStruct:{}.EntityPtrMap:{}.{}:GoodEntity`,
+	`TypeRefs.MapValueStruct:{}.IntMap:{}`,
+	`TypeRefs.MapValueStruct:{}.IntMap:{}.integer`,
+	`Root.{}:MapValueStruct`,
+}
+
+var mapValueDerefStrings = []string{
+	`Root.{}`,
+	`Root.{}.EntityMap:{}`,
+	`Root.{}.EntityMap:{}.{}`,
+	`Root.{}.EntityMap:{}.{}.IntVal:integer`,
+	`Root.{}.EntityMap:{}.{}.Message:string`,
+	`Root.{}.EntityMap:{}.{}.Same:boolean`,
+	`Root.{}.EntityPtrMap:{}`,
+	`Root.{}.EntityPtrMap:{}.{}`,
+	`Root.{}.EntityPtrMap:{}.{}.IntVal:integer`,
+	`Root.{}.EntityPtrMap:{}.{}.Message:string`,
+	`Root.{}.EntityPtrMap:{}.{}.Same:boolean`,
+	`Root.{}.IntMap:{}`,
+	`Root.{}.IntMap:{}.integer`,
+}
+
+var mapValueOpenAPIStrings = []string{
+	`openapi: 3.0.0`,
+	`components:`,
+	`  schemas:`,
+	`    GoodEntity:`,
+	`      type: object`,
+	`      properties:`,
+	`        IntVal:`,
+	`          type: integer`,
+	`          format: int64`,
+	`        Message:`,
+	`          type: string`,
+	`        Same:`,
+	`          type: boolean`,
+	`      required:`,
+	`        - IntVal`,
+	`        - Message`,
+	`        - Same`,
+	`    MapValueStruct:`,
+	`      type: object`,
+	`      properties:`,
+	`        EntityMap:`,
+	`          type: object`,
+	`          additionalProperties:`,
+	`            $ref: '#/components/schemas/GoodEntity'`,
+	`        EntityPtrMap:`,
+	`          type: object`,
+	`          additionalProperties:`,
+	`            $ref: '#/components/schemas/GoodEntity'`,
+	`        IntMap:`,
+	`          type: object`,
+	`          additionalProperties:`,
+	`            type: integer`,
+	`            format: int64`,
+	`      required:`,
+	`        - EntityMap`,
+	`        - EntityPtrMap`,
+	`        - IntMap`,
+	`paths:`,
+	`  /test/path:`,
+	`    get:`,
+	`      summary: Return data.`,
+	`      responses:`,
+	`        "200":`,
+	`          description: Success`,
+	`          content:`,
+	`            application/json:`,
+	`              schema:`,
+	`                $ref: '#/components/schemas/MapValueStruct'`,
+}
+
+var mapValueTests = []TestCase{
+	{
+		name:           "map-value-empty",
+		value:          MapValueStruct{},
+		refStrings:     mapValueRefStrings,
+		derefStrings:   mapValueDerefStrings,
+		openapiStrings: mapValueOpenAPIStrings,
+	},
+	{
+		name: "map-value-populated",
+		value: MapValueStruct{
+			EntityMap: map[string]GoodEntity{"one": {Message: "hello"}, "two": {IntVal: 123}},
+			IntMap:    map[string]int64{"one": 1},
 		},
+		refStrings:     mapValueRefStrings,
+		derefStrings:   mapValueDerefStrings,
+		openapiStrings: mapValueOpenAPIStrings,
+	},
+}
+
+// NamedEntity has named and un-named fields of every type category.
+var namedEntityRefStrings = []string{
+	`TypeRefs.GoodEntity:{}`,
+	`TypeRefs.GoodEntity:{}.IntVal:integer`,
+	`TypeRefs.GoodEntity:{}.Message:string`,
+	`TypeRefs.GoodEntity:{}.Same:boolean`,
+	`TypeRefs.NamedEntity:{}`,
+	`TypeRefs.NamedEntity:{}.NamedBool:boolean:SimpleBool`,
+	`TypeRefs.NamedEntity:{}.NamedFloat:float:SimpleFloat`,
+	`TypeRefs.NamedEntity:{}.NamedInt:integer:SimpleInt`,
+	`TypeRefs.NamedEntity:{}.NamedInterface:invalid:SimpleInterface`,
+	`TypeRefs.NamedEntity:{}.NamedMap:{}:SimpleMap`,
+	`TypeRefs.NamedEntity:{}.NamedPtr:{}:GoodEntity`,
+	`TypeRefs.NamedEntity:{}.NamedPtrSlice:[]:SimplePtrSlice`,
+	`TypeRefs.NamedEntity:{}.NamedSlice:[]:SimpleSlice`,
+	`TypeRefs.NamedEntity:{}.NamedString:string:SimpleString`,
+	`TypeRefs.NamedEntity:{}.NamedStruct:{}:SimpleStruct`,
+	`TypeRefs.NamedEntity:{}.NamedStructSlice:[]:SimpleStructSlice`,
+	`TypeRefs.NamedEntity:{}.RealBool:boolean`,
+	`TypeRefs.NamedEntity:{}.RealFloat:float`,
+	`TypeRefs.NamedEntity:{}.RealInt:integer`,
+	`TypeRefs.NamedEntity:{}.!RealInterface:invalid! ERROR:interface element is nil`,
+	`TypeRefs.NamedEntity:{}.RealMap:{}`,
+	`TypeRefs.NamedEntity:{}.RealMap:{}.integer`,
+	`TypeRefs.NamedEntity:{}.RealPtr:{}:GoodEntity`,
+	`TypeRefs.NamedEntity:{}.RealPtrSlice:[]`,
+	`TypeRefs.NamedEntity:{}.RealPtrSlice:[].{}:GoodEntity`,
+	`TypeRefs.NamedEntity:{}.RealSlice:[]`,
+	`TypeRefs.NamedEntity:{}.RealSlice:[].string`,
+	`TypeRefs.NamedEntity:{}.RealString:string`,
+	`TypeRefs.NamedEntity:{}.RealStruct:{}:GoodEntity`,
+	`TypeRefs.NamedEntity:{}.RealStructSlice:[]`,
+	`TypeRefs.NamedEntity:{}.RealStructSlice:[].{}:GoodEntity`,
+	`TypeRefs.SimpleBool:boolean`,
+	`TypeRefs.SimpleFloat:float`,
+	`TypeRefs.SimpleInt:integer`,
+	`TypeRefs.!SimpleInterface:invalid! ERROR:interface element is nil`,
+	`TypeRefs.SimpleMap:{}`,
+	`TypeRefs.SimpleMap:{}.integer`,
+	`TypeRefs.SimplePtrSlice:[]`,
+	`TypeRefs.SimplePtrSlice:[].{}:GoodEntity`,
+	`TypeRefs.SimpleSlice:[]`,
+	`TypeRefs.SimpleSlice:[].string`,
+	`TypeRefs.SimpleString:string`,
+	`TypeRefs.SimpleStruct:{}`,
+	`TypeRefs.SimpleStruct:{}.IntVal:integer`,
+	`TypeRefs.SimpleStruct:{}.Message:string`,
+	`TypeRefs.SimpleStruct:{}.Same:boolean`,
+	`TypeRefs.SimpleStructSlice:[]`,
+	`TypeRefs.SimpleStructSlice:[].{}:GoodEntity`,
+	`Root.{}:NamedEntity`,
+}
+
+var namedEntityDerefStrings = []string{
+	`Root.{}`,
+	`Root.{}.NamedBool:boolean`,
+	`Root.{}.NamedFloat:float`,
+	`Root.{}.NamedInt:integer`,
+	`Root.{}.!NamedInterface:invalid! ERROR:interface element is nil`,
+	`Root.{}.NamedMap:{}`,
+	`Root.{}.NamedMap:{}.integer`,
+	`Root.{}.NamedPtr:{}`,
+	`Root.{}.NamedPtr:{}.IntVal:integer`,
+	`Root.{}.NamedPtr:{}.Message:string`,
+	`Root.{}.NamedPtr:{}.Same:boolean`,
+	`Root.{}.NamedPtrSlice:[]`,
+	`Root.{}.NamedPtrSlice:[].{}`,
+	`Root.{}.NamedPtrSlice:[].{}.IntVal:integer`,
+	`Root.{}.NamedPtrSlice:[].{}.Message:string`,
+	`Root.{}.NamedPtrSlice:[].{}.Same:boolean`,
+	`Root.{}.NamedSlice:[]`,
+	`Root.{}.NamedSlice:[].string`,
+	`Root.{}.NamedString:string`,
+	`Root.{}.NamedStruct:{}`,
+	`Root.{}.NamedStruct:{}.IntVal:integer`,
+	`Root.{}.NamedStruct:{}.Message:string`,
+	`Root.{}.NamedStruct:{}.Same:boolean`,
+	`Root.{}.NamedStructSlice:[]`,
+	`Root.{}.NamedStructSlice:[].{}`,
+	`Root.{}.NamedStructSlice:[].{}.IntVal:integer`,
+	`Root.{}.NamedStructSlice:[].{}.Message:string`,
+	`Root.{}.NamedStructSlice:[].{}.Same:boolean`,
+	`Root.{}.RealBool:boolean`,
+	`Root.{}.RealFloat:float`,
+	`Root.{}.RealInt:integer`,
+	`Root.{}.!RealInterface:invalid! ERROR:interface element is nil`,
+	`Root.{}.RealMap:{}`,
+	`Root.{}.RealMap:{}.integer`,
+	`Root.{}.RealPtr:{}`,
+	`Root.{}.RealPtr:{}.IntVal:integer`,
+	`Root.{}.RealPtr:{}.Message:string`,
+	`Root.{}.RealPtr:{}.Same:boolean`,
+	`Root.{}.RealPtrSlice:[]`,
+	`Root.{}.RealPtrSlice:[].{}`,
+	`Root.{}.RealPtrSlice:[].{}.IntVal:integer`,
+	`Root.{}.RealPtrSlice:[].{}.Message:string`,
+	`Root.{}.RealPtrSlice:[].{}.Same:boolean`,
+	`Root.{}.RealSlice:[]`,
+	`Root.{}.RealSlice:[].string`,
+	`Root.{}.RealString:string`,
+	`Root.{}.RealStruct:{}`,
+	`Root.{}.RealStruct:{}.IntVal:integer`,
+	`Root.{}.RealStruct:{}.Message:string`,
+	`Root.{}.RealStruct:{}.Same:boolean`,
+	`Root.{}.RealStructSlice:[]`,
+	`Root.{}.RealStructSlice:[].{}`,
+	`Root.{}.RealStructSlice:[].{}.IntVal:integer`,
+	`Root.{}.RealStructSlice:[].{}.Message:string`,
+	`Root.{}.RealStructSlice:[].{}.Same:boolean`,
+}
+
+var namedEntityOpenAPIStrings = []string{
+	`openapi: 3.0.0`,
+	`components:`,
+	`  schemas:`,
+	`    GoodEntity:`,
+	`      type: object`,
+	`      properties:`,
+	`        IntVal:`,
+	`          type: integer`,
+	`          format: int64`,
+	`        Message:`,
+	`          type: string`,
+	`        Same:`,
+	`          type: boolean`,
+	`      required:`,
+	`        - IntVal`,
+	`        - Message`,
+	`        - Same`,
+	`    NamedEntity:`,
+	`      type: object`,
+	`      properties:`,
+	`        NamedBool:`,
+	`          $ref: '#/components/schemas/SimpleBool'`,
+	`        NamedFloat:`,
+	`          $ref: '#/components/schemas/SimpleFloat'`,
+	`        NamedInt:`,
+	`          $ref: '#/components/schemas/SimpleInt'`,
+	`        NamedInterface:`,
+	`          $ref: '#/components/schemas/SimpleInterface'`,
+	`        NamedMap:`,
+	`          $ref: '#/components/schemas/SimpleMap'`,
+	`        NamedPtr:`,
+	`          $ref: '#/components/schemas/GoodEntity'`,
+	`        NamedPtrSlice:`,
+	`          $ref: '#/components/schemas/SimplePtrSlice'`,
+	`        NamedSlice:`,
+	`          $ref: '#/components/schemas/SimpleSlice'`,
+	`        namedString:`,
+	`          $ref: '#/components/schemas/SimpleString'`,
+	`        NamedStruct:`,
+	`          $ref: '#/components/schemas/SimpleStruct'`,
+	`        NamedStructSlice:`,
+	`          $ref: '#/components/schemas/SimpleStructSlice'`,
+	`        RealBool:`,
+	`          type: boolean`,
+	`        RealFloat:`,
+	`          type: number`,
+	`          format: double`,
+	`        RealInt:`,
+	`          type: integer`,
+	`          format: int64`,
+	`        RealInterface:`,
+	`          type: invalid`,
+	`          error: interface element is nil`,
+	`        RealMap:`,
+	`          type: object`,
+	`          additionalProperties:`,
+	`            type: integer`,
+	`            format: int64`,
+	`        RealPtr:`,
+	`          $ref: '#/components/schemas/GoodEntity'`,
+	`        RealPtrSlice:`,
+	`          type: array`,
+	`          items:`,
+	`            $ref: '#/components/schemas/GoodEntity'`,
+	`        RealSlice:`,
+	`          type: array`,
+	`          items:`,
+	`            type: string`,
+	`        RealString:`,
+	`          type: string`,
+	`        RealStruct:`,
+	`          $ref: '#/components/schemas/GoodEntity'`,
+	`        RealStructSlice:`,
+	`          type: array`,
+	`          items:`,
+	`            $ref: '#/components/schemas/GoodEntity'`,
+	`      required:`,
+	`        - NamedBool`,
+	`        - NamedFloat`,
+	`        - NamedInt`,
+	`        - NamedMap`,
+	`        - NamedPtrSlice`,
+	`        - NamedSlice`,
+	`        - NamedStruct`,
+	`        - NamedStructSlice`,
+	`        - RealBool`,
+	`        - RealFloat`,
+	`        - RealInt`,
+	`        - RealMap`,
+	`        - RealPtrSlice`,
+	`        - RealSlice`,
+	`        - RealString`,
+	`        - RealStruct`,
+	`        - RealStructSlice`,
+	`    SimpleBool:`,
+	`      type: boolean`,
+	`    SimpleFloat:`,
+	`      type: number`,
+	`      format: double`,
+	`    SimpleInt:`,
+	`      type: integer`,
+	`      format: int64`,
+	`    SimpleInterface:`,
+	`      type: invalid`,
+	`      error: interface element is nil`,
+	`    SimpleMap:`,
+	`      type: object`,
+	`      additionalProperties:`,
+	`        type: integer`,
+	`        format: int64`,
+	`    SimplePtrSlice:`,
+	`      type: array`,
+	`      items:`,
+	`        $ref: '#/components/schemas/GoodEntity'`,
+	`    SimpleSlice:`,
+	`      type: array`,
+	`      items:`,
+	`        type: string`,
+	`    SimpleString:`,
+	`      type: string`,
+	`    SimpleStruct:`,
+	`      type: object`,
+	`      properties:`,
+	`        IntVal:`,
+	`          type: integer`,
+	`          format: int64`,
+	`        Message:`,
+	`          type: string`,
+	`        Same:`,
+	`          type: boolean`,
+	`      required:`,
+	`        - IntVal`,
+	`        - Message`,
+	`        - Same`,
+	`    SimpleStructSlice:`,
+	`      type: array`,
+	`      items:`,
+	`        $ref: '#/components/schemas/GoodEntity'`,
+	`paths:`,
+	`  /test/path:`,
+	`    get:`,
+	`      summary: Return data.`,
+	`      responses:`,
+	`        "200":`,
+	`          description: Success`,
+	`          content:`,
+	`            application/json:`,
+	`              schema:`,
+	`                $ref: '#/components/schemas/NamedEntity'`,
+}
+
+var namedEntityTests = []TestCase{
+	{
+		name:           "NamedEntity-empty",
+		value:          NamedEntity{},
+		refStrings:     namedEntityRefStrings,
+		derefStrings:   namedEntityDerefStrings,
+		openapiStrings: namedEntityOpenAPIStrings,
 	},
 }
 
@@ -1775,6 +2690,19 @@ func runTests(t *testing.T, testCases []TestCase) {
 			testName := fmt.Sprintf("%s: dialect=openapi", test.name)
 			compareStrings(t, testName, gotStrings, wantStrings)
 		}
+
+		// Test JSON Typedef schema.
+		if len(test.jtdStrings) > 0 {
+			opt := NewOptions()
+			opt.DeReference = false
+
+			r := NewJTDRenderer(opt)
+			gotStrings, _ := r.ProcessResult(gotResult)
+			wantStrings := test.jtdStrings
+
+			testName := fmt.Sprintf("%s: dialect=jtd", test.name)
+			compareStrings(t, testName, gotStrings, wantStrings)
+		}
 	}
 }
 
@@ -1783,3 +2711,4040 @@ func TestReflector_AllTests(t *testing.T) {
 		runTests(t, testCases)
 	}
 }
+
+func TestRenderer_ProcessResultErrors(t *testing.T) {
+	r := reflector.NewReflector()
+
+	// Schema without errors returns nil error.
+	schema := r.DeriveSchema(StringStruct{})
+	if _, err := NewSimpleRenderer(nil).ProcessResult(schema); err != nil {
+		t.Errorf("TEST_FAIL StringStruct: got err=%s, want nil", err)
+	}
+
+	// Cyclical references are not errors.
+	schema = r.Reset().DeriveSchema(CycleTest{})
+	if _, err := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema); err != nil {
+		t.Errorf("TEST_FAIL CycleTest: got err=%s, want nil", err)
+	}
+
+	// Schema with errors returns best-effort output and ElementErrors.
+	schema = r.Reset().DeriveSchema(InvalidTypes{})
+	gotStrings, err := NewJSONRenderer(nil).ProcessResult(schema)
+	if len(gotStrings) == 0 {
+		t.Errorf("TEST_FAIL InvalidTypes: got no output")
+	}
+
+	elemErrs, ok := err.(ElementErrors)
+	if !ok {
+		t.Fatalf("TEST_FAIL InvalidTypes: got err=%v, want ElementErrors", err)
+	}
+	compareStrings(t, "InvalidTypes: errors", elemErrs, []string{
+		`Root.{}.!Chan:invalid:chan! ERROR:kind not supported`,
+		`Root.{}.!Complex128:invalid:complex128! ERROR:kind not supported`,
+		`Root.{}.!Complex64:invalid:complex64! ERROR:kind not supported`,
+		`Root.{}.!Func:invalid:func! ERROR:kind not supported`,
+		`Root.{}."!UnsafePointer:invalid:unsafe.Pointer!" ERROR:kind not supported`,
+	})
+}
+
+// QueryParamStruct is a request struct for a GET endpoint.
+type QueryParamStruct struct {
+	Search  string     `json:"q"`
+	Limit   int        `json:"limit,omitempty"`
+	Since   *time.Time `json:"since"`
+	Tags    []string   `json:"tags"`
+	Filter  StringStruct
+	Exclude string `json:"-"`
+}
+
+func TestOpenAPIRenderer_QueryParams(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(QueryParamStruct{})
+
+	render := NewOpenAPIRenderer("/test/path", nil)
+	render.QueryParams = true
+
+	gotStrings, err := render.ProcessResult(schema)
+	compareStrings(t, "query-params: dialect=openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    QueryParamStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Filter:`,
+		`          $ref: '#/components/schemas/StringStruct'`,
+		`        limit:`,
+		`          type: integer`,
+		`        q:`,
+		`          type: string`,
+		`        since:`,
+		`          type: string`,
+		`          format: date-time`,
+		`        tags:`,
+		`          type: array`,
+		`          items:`,
+		`            type: string`,
+		`      required:`,
+		`        - Filter`,
+		`        - q`,
+		`        - tags`,
+		`    StringStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Value:`,
+		`          type: string`,
+		`      required:`,
+		`        - Value`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      parameters:`,
+		`        - in: query`,
+		`          name: Filter`,
+		`          error: query parameter must be a basic type`,
+		`        - in: query`,
+		`          name: limit`,
+		`          schema:`,
+		`            type: integer`,
+		`        - in: query`,
+		`          name: q`,
+		`          required: true`,
+		`          schema:`,
+		`            type: string`,
+		`        - in: query`,
+		`          name: since`,
+		`          schema:`,
+		`            type: string`,
+		`            format: date-time`,
+		`        - in: query`,
+		`          name: tags`,
+		`          error: query parameter must be a basic type`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+	})
+
+	compareStrings(t, "query-params: errors", err.(ElementErrors), []string{
+		`Root.{}.Filter:{} ERROR:query parameter must be a basic type`,
+		`Root.{}.Tags:[] ERROR:query parameter must be a basic type`,
+	})
+}
+
+// DateTimeOptionalStruct has required and optional datetime fields.
+type DateTimeOptionalStruct struct {
+	Created time.Time  `json:"created"`
+	Updated *time.Time `json:"updated,omitempty"`
+	Deleted *time.Time `json:"deleted"`
+}
+
+func TestRenderer_DateTimeOptional(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(DateTimeOptionalStruct{})
+
+	typeRef := schema.TypeRefs.ChildByName("DateTimeOptionalStruct", nil)
+	if typeRef == nil {
+		t.Fatalf("TEST_FAIL DateTimeOptionalStruct not found in TypeRefs")
+	}
+
+	tests := []struct {
+		name     string
+		nullable bool
+		required bool
+	}{
+		{name: "Created", nullable: false, required: true},
+		{name: "Updated", nullable: true, required: false},
+		{name: "Deleted", nullable: true, required: false},
+	}
+
+	for _, test := range tests {
+		elem := typeRef.ChildByName(test.name, nil)
+		if elem == nil {
+			t.Errorf("TEST_FAIL %s: element not found", test.name)
+			continue
+		}
+
+		if elem.Type != "datetime" {
+			t.Errorf("TEST_FAIL %s: got type %q, want %q", test.name, elem.Type, "datetime")
+		}
+		if elem.Nullable != test.nullable {
+			t.Errorf("TEST_FAIL %s: got nullable=%t, want %t", test.name, elem.Nullable, test.nullable)
+		}
+		if got := isRequired(elem, "json"); got != test.required {
+			t.Errorf("TEST_FAIL %s: got required=%t, want %t", test.name, got, test.required)
+		}
+	}
+}
+
+func TestRenderer_SchemaBuilder(t *testing.T) {
+	// Build a schema similar to ReferenceTestsStruct by hand.
+	schema := types.NewSchema(reflector.NATIVE_DIALECT)
+
+	schema.TypeRefs.AddChild(types.NewObject("BasicStruct").
+		Field("BoolVal", generictype.Boolean).
+		Field("Float64Val", generictype.Float).
+		Field("IntVal", generictype.Integer).
+		Field("StringVal", generictype.String).
+		Element())
+	schema.TypeRefs.AddChild(types.NewObject("ReferenceTestsStruct").
+		Ref("PtrPtrVal", "BasicStruct").
+		Ref("PtrVal", "BasicStruct").
+		List("PtrSlice", types.NewRef("", "BasicStruct")).
+		Element())
+	schema.Root.AddChild(types.NewRef("", "ReferenceTestsStruct").Element())
+
+	gotStrings, err := NewSimpleRenderer(nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL schema-builder: err=%s", err)
+	}
+	compareStrings(t, "schema-builder", gotStrings, []string{
+		`TypeRefs.BasicStruct:{}`,
+		`TypeRefs.BasicStruct:{}.BoolVal:boolean`,
+		`TypeRefs.BasicStruct:{}.Float64Val:float`,
+		`TypeRefs.BasicStruct:{}.IntVal:integer`,
+		`TypeRefs.BasicStruct:{}.StringVal:string`,
+		`TypeRefs.ReferenceTestsStruct:{}`,
+		`TypeRefs.ReferenceTestsStruct:{}.PtrPtrVal:{}:BasicStruct`,
+		`TypeRefs.ReferenceTestsStruct:{}.PtrSlice:[]`,
+		`TypeRefs.ReferenceTestsStruct:{}.PtrSlice:[].{}:BasicStruct`,
+		`TypeRefs.ReferenceTestsStruct:{}.PtrVal:{}:BasicStruct`,
+		`Root.{}:ReferenceTestsStruct`,
+	})
+}
+
+// OptionalCollectionStruct has collection fields with and without omitempty.
+type OptionalCollectionStruct struct {
+	Items       []GoodEntity     `json:"items,omitempty"`
+	RequiredIDs []string         `json:"requiredIDs"`
+	Labels      map[string]int64 `json:"labels,omitempty"`
+	Nested      StringStruct     `json:"nested,omitempty"`
+	Required    StringStruct     `json:"required"`
+}
+
+func TestRenderer_OptionalCollections(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(OptionalCollectionStruct{Labels: map[string]int64{"one": 1}})
+
+	typeRef := schema.TypeRefs.ChildByName("OptionalCollectionStruct", nil)
+	if typeRef == nil {
+		t.Fatalf("TEST_FAIL OptionalCollectionStruct not found in TypeRefs")
+	}
+
+	tests := map[string]bool{
+		"Items":       false,
+		"RequiredIDs": true,
+		"Labels":      false,
+		"Nested":      false,
+		"Required":    true,
+	}
+
+	for name, want := range tests {
+		elem := typeRef.ChildByName(name, nil)
+		if elem == nil {
+			t.Errorf("TEST_FAIL %s: element not found", name)
+			continue
+		}
+
+		if got := isRequired(elem, "json"); got != want {
+			t.Errorf("TEST_FAIL %s: got required=%t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestRenderer_RenderAll(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(InvalidTypes{})
+
+	specs := []RenderSpec{
+		{Name: "simple", Renderer: NewSimpleRenderer(nil)},
+		{Name: "json", Renderer: NewJSONRenderer(nil)},
+		{Name: "openapi", Renderer: NewOpenAPIRenderer("/test/path", nil)},
+	}
+
+	gotOutput, err := RenderAll(schema, specs)
+	for _, spec := range specs {
+		wantStrings, _ := spec.Renderer.ProcessResult(schema)
+		compareStrings(t, "render-all: "+spec.Name, gotOutput[spec.Name], wantStrings)
+	}
+
+	// Element errors are reported once for all renderers.
+	elemErrs, ok := err.(ElementErrors)
+	if !ok {
+		t.Fatalf("TEST_FAIL render-all: got err=%v, want ElementErrors", err)
+	}
+	if len(elemErrs) != 5 {
+		t.Errorf("TEST_FAIL render-all: got %d errors, want 5", len(elemErrs))
+	}
+
+	// The analysis is only shared while RenderAll runs.
+	for _, spec := range specs {
+		if _, ok := spec.Renderer.(analysisSharer); !ok {
+			t.Errorf("TEST_FAIL render-all: %s: does not share analysis", spec.Name)
+		}
+	}
+	if analysis := NewSimpleRenderer(nil).analyze(schema); analysis.schema != schema || len(analysis.errElems) != 5 {
+		t.Errorf("TEST_FAIL render-all: analysis: got %d errors, want 5", len(analysis.errElems))
+	}
+	shared := analyzeSchema(schema)
+	sharer := NewOpenAPIRenderer("/test/path", nil)
+	sharer.shareAnalysis(shared)
+	if sharer.analyze(schema) != shared {
+		t.Errorf("TEST_FAIL render-all: shared analysis was not used")
+	}
+	if sharer.analyze(r.Reset().DeriveSchema(StringStruct{})) == shared {
+		t.Errorf("TEST_FAIL render-all: shared analysis was used for another schema")
+	}
+	for _, spec := range specs {
+		if s, ok := spec.Renderer.(*SimpleRenderer); ok && s.analysis != nil {
+			t.Errorf("TEST_FAIL render-all: %s: analysis is still shared", spec.Name)
+		}
+	}
+
+	// Duplicate names are not allowed.
+	if _, err := RenderAll(schema, append(specs, specs[0])); err == nil {
+		t.Errorf("TEST_FAIL render-all: duplicate name did not return an error")
+	}
+}
+
+// ProtobufProfileStruct has constructs that cannot be expressed in protobuf.
+type ProtobufProfileStruct struct {
+	Good       GoodEntity
+	Interface  interface{}
+	ListOfList [][]string
+	ListOfMaps []map[string]string
+	MapOfLists map[string][]string
+	Anonymous  struct {
+		Value string
+	}
+}
+
+func TestSchema_ValidateProfile(t *testing.T) {
+	r := reflector.NewReflector()
+
+	// Valid schema has no errors.
+	schema := r.DeriveSchema(MapValueStruct{})
+	if errs := schema.ValidateProfile(types.ProtobufProfile); len(errs) > 0 {
+		t.Errorf("TEST_FAIL MapValueStruct: got %d errors, want 0: %v", len(errs), errs)
+	}
+
+	// Invalid schema has one error per construct.
+	schema = r.Reset().DeriveSchema(ProtobufProfileStruct{})
+
+	gotStrings := []string{}
+	for _, e := range schema.ValidateProfile(types.ProtobufProfile) {
+		gotStrings = append(gotStrings, e.String())
+	}
+	compareStrings(t, "protobuf-profile", gotStrings, []string{
+		`TypeRefs.ProtobufProfileStruct.Anonymous ERROR:anonymous struct not supported`,
+		`TypeRefs.ProtobufProfileStruct.Interface ERROR:untyped interface not supported`,
+		`TypeRefs.ProtobufProfileStruct.ListOfList ERROR:list of lists not supported`,
+		`TypeRefs.ProtobufProfileStruct.ListOfMaps ERROR:list of maps not supported`,
+		`TypeRefs.ProtobufProfileStruct.MapOfLists ERROR:map value must not be a list or map`,
+	})
+
+	// Unknown profile is an error.
+	if errs := schema.ValidateProfile("unknown"); len(errs) != 1 || errs[0].Error != types.UnknownProfileErr {
+		t.Errorf("TEST_FAIL unknown profile: got %v", errs)
+	}
+}
+
+// FieldOrderStruct has fields that are not in alphabetical order.
+type FieldOrderStruct struct {
+	Zebra   string
+	Apple   int
+	private bool
+	Mango   struct {
+		Second string
+		First  string
+	}
+}
+
+func TestOpenAPIRenderer_EmitFieldOrder(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(FieldOrderStruct{})
+
+	render := NewOpenAPIRenderer("/test/path", nil)
+	render.EmitFieldOrder = true
+
+	gotStrings, _ := render.ProcessResult(schema)
+	compareStrings(t, "emit-field-order", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    FieldOrderStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Apple:`,
+		`          x-order: 1`,
+		`          type: integer`,
+		`        Mango:`,
+		`          x-order: 3`,
+		`          type: object`,
+		`          properties:`,
+		`            First:`,
+		`              x-order: 1`,
+		`              type: string`,
+		`            Second:`,
+		`              x-order: 0`,
+		`              type: string`,
+		`          required:`,
+		`            - First`,
+		`            - Second`,
+		`        Zebra:`,
+		`          x-order: 0`,
+		`          type: string`,
+		`      required:`,
+		`        - Apple`,
+		`        - Mango`,
+		`        - Zebra`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/FieldOrderStruct'`,
+	})
+}
+
+// AccessStruct has fields that are only sent in requests or responses.
+type AccessStruct struct {
+	ID       string `json:"id" access:"readOnly"`
+	Name     string `json:"name"`
+	Password string `json:"password" access:"writeOnly"`
+}
+
+func TestSchema_Variant(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(AccessStruct{})
+
+	tests := []struct {
+		kind        string
+		wantStrings []string
+	}{
+		{
+			kind: types.RequestVariant,
+			wantStrings: []string{
+				`TypeRefs.AccessStruct:{}`,
+				`TypeRefs.AccessStruct:{}.Name:string`,
+				`TypeRefs.AccessStruct:{}.Password:string`,
+				`Root.{}:AccessStruct`,
+			},
+		},
+		{
+			kind: types.ResponseVariant,
+			wantStrings: []string{
+				`TypeRefs.AccessStruct:{}`,
+				`TypeRefs.AccessStruct:{}.ID:string`,
+				`TypeRefs.AccessStruct:{}.Name:string`,
+				`Root.{}:AccessStruct`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		gotStrings, _ := NewSimpleRenderer(nil).ProcessResult(schema.Variant(test.kind))
+		compareStrings(t, "variant: "+test.kind, gotStrings, test.wantStrings)
+	}
+
+	// Variants do not modify the original schema.
+	if len(schema.TypeRefs.ChildByName("AccessStruct", nil).Children) != 3 {
+		t.Errorf("TEST_FAIL variant: original schema was modified")
+	}
+
+	if schema.Variant("unknown") != nil {
+		t.Errorf("TEST_FAIL variant: unknown variant did not return nil")
+	}
+}
+
+// ExampleRequestStruct has nested types for example JSON.
+type ExampleRequestStruct struct {
+	Name    string            `json:"name"`
+	Created time.Time         `json:"created"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Parent  *ExampleRequestStruct
+	Secret  string `json:"-"`
+}
+
+func TestOpenAPIRenderer_EmitExampleRequest(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(ExampleRequestStruct{})
+
+	// Example is off by default.
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	for _, line := range gotStrings {
+		if strings.HasPrefix(line, "#") {
+			t.Fatalf("TEST_FAIL example-request: default output has comment %q", line)
+		}
+	}
+	n := len(gotStrings)
+
+	renderer := NewOpenAPIRenderer("/test/path", nil)
+	renderer.EmitExampleRequest = true
+
+	gotStrings, err := renderer.ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL example-request: err=%s", err)
+	}
+	compareStrings(t, "example-request", gotStrings[n:], []string{
+		`# Example request:`,
+		`# GET /test/path`,
+		`# {`,
+		`#   "Parent": null,`,
+		`#   "created": "2006-01-02T15:04:05Z",`,
+		`#   "labels": {`,
+		`#     "key": "string"`,
+		`#   },`,
+		`#   "name": "string",`,
+		`#   "tags": [`,
+		`#     "string"`,
+		`#   ]`,
+		`# }`,
+	})
+}
+
+// StatusEnum is a named string type with a fixed set of values.
+type StatusEnum string
+
+func (s StatusEnum) EnumValues() []string {
+	return []string{"active", "inactive", "pending"}
+}
+
+// StatusEnumStruct has an enum field with a default value.
+type StatusEnumStruct struct {
+	Status StatusEnum `json:"status" default:"pending"`
+}
+
+// BadStatusEnumStruct has an enum field with a default that is not an enum value.
+type BadStatusEnumStruct struct {
+	Status StatusEnum `json:"status" default:"deleted"`
+}
+
+func TestOpenAPIRenderer_EnumDefault(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(StatusEnumStruct{})
+
+	gotStrings, err := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL enum-default: err=%s", err)
+	}
+	compareStrings(t, "enum-default", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    StatusEnum:`,
+		`      type: string`,
+		`      enum:`,
+		`        - "active"`,
+		`        - "inactive"`,
+		`        - "pending"`,
+		`    StatusEnumStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        status:`,
+		`          allOf:`,
+		`            - $ref: '#/components/schemas/StatusEnum'`,
+		`          default: "pending"`,
+		`      required:`,
+		`        - status`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/StatusEnumStruct'`,
+	})
+
+	// Default must be one of the enum values.
+	opt := NewOptions()
+	opt.DeReference = true
+	schema = r.Reset().DeriveSchema(BadStatusEnumStruct{})
+	gotStrings, err = NewSimpleRenderer(opt).ProcessResult(schema)
+	if err == nil {
+		t.Errorf("TEST_FAIL enum-default: bad default did not return an error")
+	}
+	compareStrings(t, "enum-default: bad default", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.!Status:string! ERROR:default must be one of the enum values`,
+	})
+}
+
+// Status is a named string type whose values are registered with RegisterEnum.
+type Status string
+
+const (
+	StatusOpen   Status = "open"
+	StatusClosed Status = "closed"
+	StatusMerged Status = "merged"
+)
+
+// RegisteredEnumStruct has a field with registered enum values.
+type RegisteredEnumStruct struct {
+	Status Status `json:"status"`
+}
+
+func TestOpenAPIRenderer_RegisterEnum(t *testing.T) {
+	r := reflector.NewReflector()
+	r.RegisterEnum(StatusOpen, StatusOpen, StatusClosed, StatusMerged)
+
+	// Registrations are kept by Reset.
+	schema := r.Reset().DeriveSchema(RegisteredEnumStruct{})
+
+	gotStrings, err := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL register-enum: err=%s", err)
+	}
+	compareStrings(t, "register-enum", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    RegisteredEnumStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        status:`,
+		`          $ref: '#/components/schemas/Status'`,
+		`      required:`,
+		`        - status`,
+		`    Status:`,
+		`      type: string`,
+		`      enum:`,
+		`        - "open"`,
+		`        - "closed"`,
+		`        - "merged"`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/RegisteredEnumStruct'`,
+	})
+}
+
+func TestRenderer_NoTypeRefs(t *testing.T) {
+	// JSON samples only have anonymous objects so there are no TypeRefs.
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(fromJSON([]byte(`{"name":"x","owner":{"active":true}}`)))
+
+	gotStrings, err := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL no-typerefs: err=%s", err)
+	}
+	compareStrings(t, "no-typerefs: dialect=openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  Name:`,
+		`                    type: string`,
+		`                  Owner:`,
+		`                    type: object`,
+		`                    properties:`,
+		`                      Active:`,
+		`                        type: boolean`,
+	})
+
+	gotStrings, _ = NewJTDRenderer(nil).ProcessResult(schema)
+	for _, line := range gotStrings {
+		if strings.Contains(line, `"definitions"`) {
+			t.Errorf("TEST_FAIL no-typerefs: dialect=jtd has definitions")
+		}
+	}
+}
+
+func TestOpenAPIRenderer_CycleAsRef(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(TreeStruct{})
+
+	pathStrings := []string{
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  Children:`,
+		`                    type: array`,
+		`                    items:`,
+		`                      allOf:`,
+		`                        - $ref: '#/components/schemas/TreeStruct'`,
+		`                      error: cyclical reference`,
+		`                  Index:`,
+		`                    type: object`,
+		`                    additionalProperties:`,
+		`                      allOf:`,
+		`                        - $ref: '#/components/schemas/TreeStruct'`,
+		`                      error: cyclical reference`,
+		`                  Name:`,
+		`                    type: string`,
+		`                required:`,
+		`                  - Children`,
+		`                  - Index`,
+		`                  - Name`,
+	}
+
+	// TypeRefs are rendered because cyclical references are kept as references.
+	wantStrings := append([]string{}, treeOpenAPIStrings[:20]...)
+	wantStrings = append(wantStrings, pathStrings...)
+
+	opt := NewOptions()
+	opt.DeReference = true
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "cycle-as-ref: default", gotStrings, wantStrings)
+
+	// Options are not changed while TypeRefs are rendered.
+	if !opt.DeReference {
+		t.Errorf("TEST_FAIL cycle-as-ref: DeReference option was changed")
+	}
+
+	// Cyclical references are clean references without errors.
+	// - Without the error, the reference has no siblings and is not wrapped in allOf.
+	wantStrings = append([]string{}, treeOpenAPIStrings[:20]...)
+	for _, line := range pathStrings {
+		switch {
+		case strings.HasSuffix(line, "error: cyclical reference"), strings.HasSuffix(line, "allOf:"):
+		case strings.HasSuffix(line, "- $ref: '#/components/schemas/TreeStruct'"):
+			wantStrings = append(wantStrings, strings.Replace(line, "  - $ref", "$ref", 1))
+		default:
+			wantStrings = append(wantStrings, line)
+		}
+	}
+
+	opt = NewOptions()
+	opt.DeReference = true
+	opt.CycleAsRef = true
+	gotStrings, _ = NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "cycle-as-ref: enabled", gotStrings, wantStrings)
+}
+
+// NullableStruct has a nullable field.
+type NullableStruct struct {
+	Count int     `json:"count"`
+	Name  *string `json:"name"`
+}
+
+func TestOpenAPIRenderer_NullableKeyword(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(&NullableStruct{})
+
+	wantStrings := func(nullableLines ...string) []string {
+		out := []string{
+			`openapi: 3.0.0`,
+			`components:`,
+			`  schemas:`,
+			`    NullableStruct:`,
+			`      type: object`,
+			`      properties:`,
+			`        count:`,
+			`          type: integer`,
+			`        name:`,
+		}
+		out = append(out, nullableLines...)
+		return append(out,
+			`          type: string`,
+			`      required:`,
+			`        - count`,
+			`paths:`,
+			`  /test/path:`,
+			`    get:`,
+			`      summary: Return data.`,
+			`      responses:`,
+			`        "200":`,
+			`          description: Success`,
+			`          content:`,
+			`            application/json:`,
+			`              schema:`,
+			`                $ref: '#/components/schemas/NullableStruct'`,
+		)
+	}
+
+	tests := []struct {
+		keyword     string
+		wantStrings []string
+	}{
+		{keyword: "", wantStrings: wantStrings()},
+		{keyword: NullableOpenAPI3, wantStrings: wantStrings(`          nullable: true`)},
+		{keyword: NullableSwagger2, wantStrings: wantStrings(`          x-nullable: true`)},
+	}
+
+	for _, test := range tests {
+		renderer := NewOpenAPIRenderer("/test/path", nil)
+		renderer.NullableKeyword = test.keyword
+
+		gotStrings, _ := renderer.ProcessResult(schema)
+		compareStrings(t, "nullable-keyword: "+test.keyword, gotStrings, test.wantStrings)
+	}
+}
+
+// Money is rendered as a decimal string by a custom type renderer.
+type Money struct {
+	Units int64
+	Nanos int32
+}
+
+// InvoiceStruct has a Money field.
+type InvoiceStruct struct {
+	Number string `json:"number"`
+	Total  Money  `json:"total"`
+}
+
+func TestRenderer_RegisterTypeRenderer(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(InvoiceStruct{})
+
+	opt := NewOptions()
+	opt.DeReference = true
+	opt.RegisterTypeRenderer("Money", func(t *types.TypeElement, r Renderer) []string {
+		return []string{
+			r.Prefix() + t.GetNativeType("json").Name + ":",
+			r.Prefix() + "  type: string",
+			r.Prefix() + `  pattern: '^-?[0-9]+\.[0-9]{2}$'`,
+		}
+	})
+
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "type-renderer: registered", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  number:`,
+		`                    type: string`,
+		`                  total:`,
+		`                    type: string`,
+		`                    pattern: '^-?[0-9]+\.[0-9]{2}$'`,
+		`                required:`,
+		`                  - number`,
+		`                  - total`,
+	})
+
+	// Functions are only used by renderers with the options.
+	defaultOpt := NewOptions()
+	defaultOpt.DeReference = true
+
+	wantStrings := []string{
+		`Root.{}`,
+		`Root.{}.Number:string`,
+		`Root.{}.Total:{}`,
+		`Root.{}.Total:{}.Nanos:integer`,
+		`Root.{}.Total:{}.Units:integer`,
+	}
+
+	gotStrings, _ = NewSimpleRenderer(defaultOpt).ProcessResult(schema)
+	compareStrings(t, "type-renderer: other options", gotStrings, wantStrings)
+
+	// Default handling is restored when the function is removed.
+	opt.RegisterTypeRenderer("Money", nil)
+
+	gotStrings, _ = NewSimpleRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "type-renderer: removed", gotStrings, wantStrings)
+}
+
+// OptionalPointerStruct has all combinations of pointer and omitempty.
+type OptionalPointerStruct struct {
+	OptionalPtr    *string `json:"optionalPtr,omitempty"`
+	NullablePtr    *string `json:"nullablePtr"`
+	OptionalString string  `json:"optionalString,omitempty"`
+	RequiredString string  `json:"requiredString"`
+}
+
+func TestRenderer_OptionalPointer(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(OptionalPointerStruct{})
+
+	// A pointer with omitempty is optional and nullable. Nullability comes only from the pointer.
+	typeRef := schema.TypeRefs.ChildByName("OptionalPointerStruct", nil)
+	for _, test := range []struct {
+		name     string
+		nullable bool
+		required bool
+	}{
+		{name: "OptionalPtr", nullable: true, required: false},
+		{name: "NullablePtr", nullable: true, required: false},
+		{name: "OptionalString", nullable: false, required: false},
+		{name: "RequiredString", nullable: false, required: true},
+	} {
+		elem := typeRef.ChildByName(test.name, nil)
+		if elem.Nullable != test.nullable {
+			t.Errorf("TEST_FAIL %s: got nullable=%t, want %t", test.name, elem.Nullable, test.nullable)
+		}
+		if got := isRequired(elem, "json"); got != test.required {
+			t.Errorf("TEST_FAIL %s: got required=%t, want %t", test.name, got, test.required)
+		}
+	}
+
+	renderer := NewOpenAPIRenderer("/test/path", nil)
+	renderer.NullableKeyword = NullableOpenAPI3
+
+	gotStrings, _ := renderer.ProcessResult(schema)
+	compareStrings(t, "optional-pointer: dialect=openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    OptionalPointerStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        nullablePtr:`,
+		`          nullable: true`,
+		`          type: string`,
+		`        optionalPtr:`,
+		`          nullable: true`,
+		`          type: string`,
+		`        optionalString:`,
+		`          type: string`,
+		`        requiredString:`,
+		`          type: string`,
+		`      required:`,
+		`        - requiredString`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/OptionalPointerStruct'`,
+	})
+
+	// JTD separates optional (optionalProperties) from nullable.
+	gotStrings, _ = NewJTDRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "optional-pointer: dialect=jtd", gotStrings, []string{
+		`{`,
+		`  "definitions": {`,
+		`    "OptionalPointerStruct": {`,
+		`      "optionalProperties": {`,
+		`        "optionalPtr": {`,
+		`          "nullable": true,`,
+		`          "type": "string"`,
+		`        },`,
+		`        "optionalString": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "properties": {`,
+		`        "nullablePtr": {`,
+		`          "nullable": true,`,
+		`          "type": "string"`,
+		`        },`,
+		`        "requiredString": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      }`,
+		`    }`,
+		`  },`,
+		`  "ref": "OptionalPointerStruct"`,
+		`}`,
+	})
+}
+
+func TestSchema_Complexity(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  types.ComplexityReport
+	}{
+		{
+			name:  "tree",
+			value: TreeStruct{},
+			want:  types.ComplexityReport{Types: 1, Fields: 3, MaxDepth: 3, Cycles: 2},
+		},
+		{
+			name:  "invalid",
+			value: InvalidTypes{},
+			want:  types.ComplexityReport{Types: 1, Fields: 5, MaxDepth: 2, Errors: 5},
+		},
+	}
+
+	for _, test := range tests {
+		r := reflector.NewReflector()
+		got := r.DeriveSchema(test.value).Complexity()
+		if got != test.want {
+			t.Errorf("TEST_FAIL complexity: %s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+// NamedScalarPtrStruct has a pointer to a named scalar.
+type NamedScalarPtrStruct struct {
+	Ptr *SimpleInt
+	Val SimpleInt
+}
+
+func TestRenderer_PointerToNamedScalar(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(NamedScalarPtrStruct{})
+
+	// The pointer keeps the TypeRef of the named scalar and is nullable.
+	gotStrings, _ := NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "named-scalar-ptr: deref=false", gotStrings, []string{
+		`TypeRefs.NamedScalarPtrStruct:{}`,
+		`TypeRefs.NamedScalarPtrStruct:{}.Ptr:integer:SimpleInt`,
+		`TypeRefs.NamedScalarPtrStruct:{}.Val:integer:SimpleInt`,
+		`TypeRefs.SimpleInt:integer`,
+		`Root.{}:NamedScalarPtrStruct`,
+	})
+
+	pathStrings := []string{
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+	}
+
+	// TypeRefs render as a nullable reference to the named scalar.
+	renderer := NewOpenAPIRenderer("/test/path", nil)
+	renderer.NullableKeyword = NullableOpenAPI3
+
+	gotStrings, _ = renderer.ProcessResult(schema)
+	wantStrings := []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    NamedScalarPtrStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Ptr:`,
+		`          nullable: true`,
+		`          allOf:`,
+		`            - $ref: '#/components/schemas/SimpleInt'`,
+		`        Val:`,
+		`          $ref: '#/components/schemas/SimpleInt'`,
+		`      required:`,
+		`        - Val`,
+		`    SimpleInt:`,
+		`      type: integer`,
+		`      format: int64`,
+	}
+	wantStrings = append(wantStrings, pathStrings...)
+	wantStrings = append(wantStrings, `                $ref: '#/components/schemas/NamedScalarPtrStruct'`)
+	compareStrings(t, "named-scalar-ptr: dialect=openapi deref=false", gotStrings, wantStrings)
+
+	// De-referenced scalars are inline integers.
+	opt := NewOptions()
+	opt.DeReference = true
+	renderer = NewOpenAPIRenderer("/test/path", opt)
+	renderer.NullableKeyword = NullableOpenAPI3
+
+	gotStrings, _ = renderer.ProcessResult(schema)
+	wantStrings = append([]string{`openapi: 3.0.0`}, pathStrings...)
+	wantStrings = append(wantStrings,
+		`                type: object`,
+		`                properties:`,
+		`                  Ptr:`,
+		`                    nullable: true`,
+		`                    type: integer`,
+		`                    format: int64`,
+		`                  Val:`,
+		`                    type: integer`,
+		`                    format: int64`,
+		`                required:`,
+		`                  - Val`,
+	)
+	compareStrings(t, "named-scalar-ptr: dialect=openapi deref=true", gotStrings, wantStrings)
+}
+
+// ProtobufNumberStruct has a tagged field number and untagged fields.
+type ProtobufNumberStruct struct {
+	ID    string `protobuf:"bytes,1,opt,name=id,proto3"`
+	Name  string
+	Email string
+}
+
+// ProtobufNumberReorderedStruct has the fields of ProtobufNumberStruct in a different order with a new field.
+type ProtobufNumberReorderedStruct struct {
+	Added string
+	Email string
+	Name  string
+	ID    string `protobuf:"bytes,1,opt,name=id,proto3"`
+}
+
+func TestProtobufFieldNumbers(t *testing.T) {
+	numbersOf := func(value interface{}, typeName string) map[string]int {
+		r := reflector.NewReflector()
+		schema := r.DeriveSchema(value)
+
+		numbers, err := ProtobufFieldNumbers(schema.TypeRefs.ChildByName(typeName, nil))
+		if err != nil {
+			t.Fatalf("TEST_FAIL %s: err=%s", typeName, err)
+		}
+		return numbers
+	}
+
+	numbers := numbersOf(ProtobufNumberStruct{}, "ProtobufNumberStruct")
+	reordered := numbersOf(ProtobufNumberReorderedStruct{}, "ProtobufNumberReorderedStruct")
+
+	if numbers["ID"] != 1 {
+		t.Errorf("TEST_FAIL tagged: got ID=%d, want 1", numbers["ID"])
+	}
+
+	// Numbers do not change when fields are reordered or added.
+	for _, name := range []string{"ID", "Name", "Email"} {
+		if numbers[name] != reordered[name] {
+			t.Errorf("TEST_FAIL reordered: %s: got %d, want %d", name, reordered[name], numbers[name])
+		}
+	}
+
+	// A hash collision with a tagged number moves to the next number.
+	elem := types.NewObject("Collision").
+		Field("Name", generictype.String).
+		Field("Other", generictype.String).
+		Element()
+	elem.ChildByName("Other", nil).NativeDefault().Options.AddKeyVal("ProtobufField", fmt.Sprintf("%d", hashFieldNumber("Name")))
+
+	numbers, err := ProtobufFieldNumbers(elem)
+	if err != nil {
+		t.Fatalf("TEST_FAIL collision: err=%s", err)
+	}
+	if want := hashFieldNumber("Name") + 1; numbers["Name"] != want {
+		t.Errorf("TEST_FAIL collision: got Name=%d, want %d", numbers["Name"], want)
+	}
+
+	// Duplicate tagged numbers are an error.
+	elem.ChildByName("Name", nil).NativeDefault().Options.AddKeyVal("ProtobufField", fmt.Sprintf("%d", hashFieldNumber("Name")))
+	if _, err := ProtobufFieldNumbers(elem); err == nil {
+		t.Errorf("TEST_FAIL duplicate: got nil error")
+	}
+}
+
+// ProtoStruct has fields of all kinds that the proto renderer supports and some that it does not.
+type ProtoStruct struct {
+	ID        string `json:"id" protobuf:"bytes,10,opt,name=id,proto3"`
+	Count     int32  `json:"count"`
+	Total     int64  `json:"total"`
+	Ratio     float32
+	Score     float64
+	Active    bool
+	Data      []byte
+	Tags      []string
+	Matrix    [][]int
+	Labels    map[string]string
+	Items     []BasicStruct
+	Owner     *BasicStruct
+	CreatedAt time.Time
+	Extra     interface{}
+	Address   struct {
+		Street string
+	}
+}
+
+func TestProtoRenderer(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(ProtoStruct{})
+
+	gotStrings, _ := NewProtoRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "proto: struct", gotStrings, []string{
+		`syntax = "proto3";`,
+		``,
+		`import "google/protobuf/timestamp.proto";`,
+		``,
+		`message BasicStruct {`,
+		`  bool BoolVal = 3385;`,
+		`  double Float64Val = 397;`,
+		`  int64 IntVal = 10792;`,
+		`  string StringVal = 3408;`,
+		`}`,
+		``,
+		`message ProtoStruct {`,
+		`  message AddressMessage {`,
+		`    string Street = 17882;`,
+		`  }`,
+		`  bool Active = 11919;`,
+		`  AddressMessage Address = 613;`,
+		`  int32 count = 6156;`,
+		`  google.protobuf.Timestamp CreatedAt = 4020;`,
+		`  bytes Data = 2651;`,
+		`  // unsupported: Extra = 695;`,
+		`  string id = 10;`,
+		`  repeated BasicStruct Items = 18703;`,
+		`  map<string, string> Labels = 15390;`,
+		`  // unsupported: Matrix = 2864;`,
+		`  BasicStruct Owner = 15454;`,
+		`  float Ratio = 18593;`,
+		`  double Score = 10124;`,
+		`  repeated string Tags = 17799;`,
+		`  int64 total = 3437;`,
+		`}`,
+	})
+
+	// Field numbers are the same for every run.
+	againStrings, _ := NewProtoRenderer(nil).ProcessResult(reflector.NewReflector().DeriveSchema(ProtoStruct{}))
+	compareStrings(t, "proto: stable", againStrings, gotStrings)
+}
+
+// MarkdownStruct has documented fields of several kinds and a field with an error.
+type MarkdownStruct struct {
+	ID      string                  `json:"id" b9schema:"description=Unique ID | never reused."`
+	Owner   *DescribedOwner         `json:"owner" b9schema:"description=Owner of the record."`
+	Members []DescribedOwner        `json:"members"`
+	Labels  map[string]string       `json:"labels"`
+	Created time.Time               `json:"created"`
+	Meta    struct{ Source string } `json:"meta"`
+	Events  chan int                `json:"events"`
+}
+
+func TestMarkdownRenderer(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(MarkdownStruct{})
+
+	gotStrings, _ := NewMarkdownRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "markdown: struct", gotStrings, []string{
+		`### DescribedOwner`,
+		``,
+		`| Field | Type | Nullable | Description |`,
+		`| --- | --- | --- | --- |`,
+		`| name | string | no | Full name. May span lines. |`,
+		``,
+		`### MarkdownStruct`,
+		``,
+		`| Field | Type | Nullable | Description | Error |`,
+		`| --- | --- | --- | --- | --- |`,
+		`| created | datetime | no |  |  |`,
+		`| events | invalid:chan | no |  | kind not supported |`,
+		`| id | string | no | Unique ID \| never reused. |  |`,
+		`| labels | map[string]string | no |  |  |`,
+		`| members | [DescribedOwner](#describedowner)[] | no |  |  |`,
+		`| meta | object | no |  |  |`,
+		`| owner | [DescribedOwner](#describedowner) | yes | Owner of the record. |  |`,
+	})
+}
+
+// ConstraintStruct has validation constraints in b9schema struct tags.
+type ConstraintStruct struct {
+	Age   int     `json:"age" b9schema:"minimum=0,maximum=150"`
+	Score float64 `json:"score" b9schema:"minimum=0.5"`
+	Count uint    `json:"count" b9schema:"minimum=1"`
+	Code  string  `json:"code" b9schema:"minLength=2,maxLength=8,pattern=^[a-z]{2,8}$,description=Lowercase code."`
+}
+
+// BadConstraintStruct has constraints that do not apply to their types or have invalid values.
+type BadConstraintStruct struct {
+	Age   int    `json:"age" b9schema:"pattern=^[0-9]+$"`
+	Name  string `json:"name" b9schema:"minimum=1"`
+	Code  string `json:"code" b9schema:"maxLength=-1"`
+	Empty int    `json:"empty" b9schema:",=5"`
+}
+
+func TestRenderer_Constraints(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(ConstraintStruct{})
+
+	gotStrings, err := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL constraints: err=%s", err)
+	}
+	compareStrings(t, "constraints: openapi", gotStrings[:25], []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    ConstraintStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        age:`,
+		`          type: integer`,
+		`          minimum: 0`,
+		`          maximum: 150`,
+		`        code:`,
+		`          description: Lowercase code.`,
+		`          type: string`,
+		`          minLength: 2`,
+		`          maxLength: 8`,
+		`          pattern: "^[a-z]{2,8}$"`,
+		`        count:`,
+		`          type: integer`,
+		`          minimum: 1`,
+		`        score:`,
+		`          type: number`,
+		`          format: double`,
+		`          minimum: 0.5`,
+		`      required:`,
+		`        - age`,
+	})
+
+	gotStrings, _ = NewJSONSchemaRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "constraints: json schema", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "allOf": [`,
+		`    {`,
+		`      "$ref": "#/definitions/ConstraintStruct"`,
+		`    }`,
+		`  ],`,
+		`  "definitions": {`,
+		`    "ConstraintStruct": {`,
+		`      "properties": {`,
+		`        "age": {`,
+		`          "maximum": 150,`,
+		`          "minimum": 0,`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "code": {`,
+		`          "description": "Lowercase code.",`,
+		`          "maxLength": 8,`,
+		`          "minLength": 2,`,
+		`          "pattern": "^[a-z]{2,8}$",`,
+		`          "type": "string"`,
+		`        },`,
+		`        "count": {`,
+		`          "minimum": 1,`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "score": {`,
+		`          "minimum": 0.5,`,
+		`          "type": "number"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "age",`,
+		`        "code",`,
+		`        "count",`,
+		`        "score"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+
+	// Constraints that do not apply are errors.
+	opt := NewOptions()
+	opt.DeReference = true
+	schema = r.Reset().DeriveSchema(BadConstraintStruct{})
+	gotStrings, err = NewSimpleRenderer(opt).ProcessResult(schema)
+	if err == nil {
+		t.Errorf("TEST_FAIL constraints: bad constraints did not return an error")
+	}
+	compareStrings(t, "constraints: bad", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.!Age:integer! ERROR:constraint does not apply to type`,
+		`Root.{}.!Code:string! ERROR:constraint value is not valid`,
+		`Root.{}.!Empty:integer! ERROR:constraint value is not valid`,
+		`Root.{}.!Name:string! ERROR:constraint does not apply to type`,
+	})
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(MainStruct{})
+
+	// Go values validate against the schema of their type.
+	if errs := schema.Validate(MainStruct{}); len(errs) > 0 {
+		t.Errorf("TEST_FAIL validate: Go value: got %v, want no errors", errs)
+	}
+
+	// Decoded JSON payloads are validated by JSON name.
+	good := fromJSON([]byte(`{
+		"intVal": 1,
+		"FloatVal": 1.5,
+		"BoolVal": true,
+		"SliceVal": [1, 2],
+		"InterfaceVal": {"any": "value"},
+		"StructPtr": null,
+		"StructVal": {
+			"Status": "ok", "IntVal": 2, "FloatVal": 3, "Same": false, "Simple": 4,
+			"MapNil": null, "MapVal": {"a": 5},
+			"Good": {"Message": "hi", "IntVal": 6, "Same": true},
+			"GoodSlice": [{"Message": "hi", "IntVal": 7, "Same": true}],
+			"GoodPtrSlice": null,
+			"AnonStruct": {"FieldOne": "one", "FieldTwo": 2, "FieldThree": 3.5}
+		},
+		"StringPtr": null,
+		"DuplicateOne": "one",
+		"duplicateOne": "two"
+	}`))
+	got := validationStrings(schema.Validate(good))
+	compareStrings(t, "validate: good", got, []string{})
+
+	bad := fromJSON([]byte(`{
+		"stringVal": 1,
+		"FloatVal": "1.5",
+		"BoolVal": true,
+		"SliceVal": [1, "two", 3.5],
+		"StructPtr": {"Message": "hi", "IntVal": "x"},
+		"StructVal": {"Status": "ok", "IntVal": 2, "FloatVal": 3, "Same": false, "Simple": 4, "MapVal": {"a": true}}
+	}`))
+	got = validationStrings(schema.Validate(bad))
+	compareStrings(t, "validate: bad", got, []string{
+		`$.DuplicateOne: expected string, got missing`,
+		`$.FloatVal: expected float, got string`,
+		`$.SliceVal[1]: expected integer, got string`,
+		`$.SliceVal[2]: expected integer, got number`,
+		`$.StructPtr.IntVal: expected integer, got string`,
+		`$.StructPtr.Same: expected boolean, got missing`,
+		`$.StructVal.AnonStruct: expected struct, got missing`,
+		`$.StructVal.Good: expected struct, got missing`,
+		`$.StructVal.GoodPtrSlice: expected list, got missing`,
+		`$.StructVal.GoodSlice: expected list, got missing`,
+		`$.StructVal.MapNil: expected struct, got missing`,
+		`$.StructVal.MapVal.a: expected integer, got boolean`,
+		`$.duplicateOne: expected string, got missing`,
+		`$.intVal: expected integer, got missing`,
+		`$.stringVal: expected string, got number`,
+	})
+}
+
+func TestWalk(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(MainStruct{})
+
+	// Paths collected by Walk under Root are the lines of the de-referenced simple renderer.
+	opt := NewOptions()
+	opt.DeReference = true
+	r := NewSimpleRenderer(opt)
+	wantStrings, _ := r.ProcessResult(schema)
+
+	gotStrings := []string{}
+	typeRefCount := 0
+	maxDepth := 0
+	err := types.Walk(schema, func(e *types.TypeElement, depth int) error {
+		if e.Type == generictype.Root.String() {
+			return nil
+		}
+		if path := r.Pre(e)[0]; strings.HasPrefix(path, "TypeRefs.") {
+			typeRefCount++
+		} else {
+			gotStrings = append(gotStrings, path)
+		}
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("TEST_FAIL walk: err=%s", err)
+	}
+	compareStrings(t, "walk: paths", gotStrings, wantStrings)
+	if typeRefCount != 38 {
+		t.Errorf("TEST_FAIL walk: TypeRefs got %d elements, want 38", typeRefCount)
+	}
+	if maxDepth != 5 {
+		t.Errorf("TEST_FAIL walk: max depth got %d, want 5", maxDepth)
+	}
+
+	// Walk stops at the first error.
+	stopErr := fmt.Errorf("stop")
+	count := 0
+	err = types.Walk(schema, func(e *types.TypeElement, depth int) error {
+		count++
+		if count == 3 {
+			return stopErr
+		}
+		return nil
+	})
+	if err != stopErr || count != 3 {
+		t.Errorf("TEST_FAIL walk: stop got err=%v count=%d, want err=%v count=3", err, count, stopErr)
+	}
+}
+
+// validationStrings returns validation errors as strings for compareStrings.
+func validationStrings(errs []types.ValidationError) []string {
+	out := []string{}
+	for _, err := range errs {
+		out = append(out, err.Error())
+	}
+	return out
+}
+
+// QuotedNameStruct has JSON names that are not plain YAML keys.
+type QuotedNameStruct struct {
+	FooBar  string `json:"foo.bar"`
+	Hash    string `json:"#hash"`
+	Colon   string `json:"key: value"`
+	Numeric string `json:"123"`
+	Note    string `json:"note" b9schema:"description=First line.\nSecond line: with a colon."`
+}
+
+func TestOpenAPIRenderer_YAMLQuoting(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(QuotedNameStruct{})
+
+	gotStrings, err := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL yaml-quoting: err=%s", err)
+	}
+	compareStrings(t, "yaml-quoting", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    QuotedNameStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        'key: value':`,
+		`          type: string`,
+		`        foo.bar:`,
+		`          type: string`,
+		`        '#hash':`,
+		`          type: string`,
+		`        note:`,
+		`          description: |-`,
+		`            First line.`,
+		`            Second line: with a colon.`,
+		`          type: string`,
+		`        "123":`,
+		`          type: string`,
+		`      required:`,
+		`        - '#hash'`,
+		`        - "123"`,
+		`        - foo.bar`,
+		`        - 'key: value'`,
+		`        - note`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/QuotedNameStruct'`,
+	})
+
+	// Output is valid YAML with the JSON names as keys.
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(strings.Join(gotStrings, "\n")), &doc); err != nil {
+		t.Fatalf("TEST_FAIL yaml-quoting: output is not valid YAML: %s", err)
+	}
+	properties := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})["QuotedNameStruct"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, name := range []string{"foo.bar", "#hash", "key: value", "123", "note"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("TEST_FAIL yaml-quoting: property %q not found", name)
+		}
+	}
+}
+
+// CatchAllStruct has known fields and a catch-all map for other properties.
+type CatchAllStruct struct {
+	Name   string                 `json:"name"`
+	Labels CatchAllLabels         `json:"labels"`
+	Extra  map[string]interface{} `json:",inline"`
+}
+
+// CatchAllLabels has a typed catch-all map.
+type CatchAllLabels struct {
+	Count  int64             `json:"count"`
+	Values map[string]string `json:",inline"`
+}
+
+func TestOpenAPIRenderer_CatchAll(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(CatchAllStruct{})
+
+	gotStrings, err := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL catch-all: err=%s", err)
+	}
+	compareStrings(t, "catch-all: dialect=openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    CatchAllLabels:`,
+		`      type: object`,
+		`      properties:`,
+		`        count:`,
+		`          type: integer`,
+		`          format: int64`,
+		`      required:`,
+		`        - count`,
+		`      additionalProperties:`,
+		`        type: string`,
+		`    CatchAllStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        labels:`,
+		`          $ref: '#/components/schemas/CatchAllLabels'`,
+		`        name:`,
+		`          type: string`,
+		`      required:`,
+		`        - labels`,
+		`        - name`,
+		`      additionalProperties: true`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/CatchAllStruct'`,
+	})
+
+	gotStrings, _ = NewJTDRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "catch-all: dialect=jtd", gotStrings, []string{
+		`{`,
+		`  "definitions": {`,
+		`    "CatchAllLabels": {`,
+		`      "additionalProperties": true,`,
+		`      "properties": {`,
+		`        "count": {`,
+		`          "metadata": {`,
+		`            "note": "int64 is rendered as int32 because JTD has no 64-bit integer type"`,
+		`          },`,
+		`          "type": "int32"`,
+		`        }`,
+		`      }`,
+		`    },`,
+		`    "CatchAllStruct": {`,
+		`      "additionalProperties": true,`,
+		`      "properties": {`,
+		`        "labels": {`,
+		`          "ref": "CatchAllLabels"`,
+		`        },`,
+		`        "name": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      }`,
+		`    }`,
+		`  },`,
+		`  "ref": "CatchAllStruct"`,
+		`}`,
+	})
+}
+
+func TestSchema_Transform(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(DateTimeOptionalStruct{})
+
+	// Render datetimes as plain strings.
+	transformed := schema.Transform(func(t *types.TypeElement) {
+		if t.Type == generictype.DateTime.String() {
+			t.Type = generictype.String.String()
+		}
+	})
+
+	gotStrings, _ := NewSimpleRenderer(nil).ProcessResult(transformed)
+	compareStrings(t, "transform: transformed", gotStrings, []string{
+		`TypeRefs.DateTimeOptionalStruct:{}`,
+		`TypeRefs.DateTimeOptionalStruct:{}.Created:string`,
+		`TypeRefs.DateTimeOptionalStruct:{}.Deleted:string`,
+		`TypeRefs.DateTimeOptionalStruct:{}.Updated:string`,
+		`Root.{}:DateTimeOptionalStruct`,
+	})
+
+	// The original schema is not changed.
+	gotStrings, _ = NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "transform: original", gotStrings, []string{
+		`TypeRefs.DateTimeOptionalStruct:{}`,
+		`TypeRefs.DateTimeOptionalStruct:{}.Created:datetime`,
+		`TypeRefs.DateTimeOptionalStruct:{}.Deleted:datetime`,
+		`TypeRefs.DateTimeOptionalStruct:{}.Updated:datetime`,
+		`Root.{}:DateTimeOptionalStruct`,
+	})
+}
+
+// InternalStruct has an internal field.
+type InternalStruct struct {
+	Name    string `json:"name"`
+	TraceID string `json:"traceID,internal"`
+}
+
+func TestOpenAPIRenderer_Internal(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(InternalStruct{})
+
+	wantStrings := func(fieldLines ...string) []string {
+		out := []string{
+			`openapi: 3.0.0`,
+			`components:`,
+			`  schemas:`,
+			`    InternalStruct:`,
+			`      type: object`,
+			`      properties:`,
+			`        name:`,
+			`          type: string`,
+		}
+		out = append(out, fieldLines...)
+		return append(out,
+			`paths:`,
+			`  /test/path:`,
+			`    get:`,
+			`      summary: Return data.`,
+			`      responses:`,
+			`        "200":`,
+			`          description: Success`,
+			`          content:`,
+			`            application/json:`,
+			`              schema:`,
+			`                $ref: '#/components/schemas/InternalStruct'`,
+		)
+	}
+
+	// Default is to render internal fields like other fields.
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	compareStrings(t, "internal: default", gotStrings, wantStrings(
+		`        traceID:`,
+		`          type: string`,
+		`      required:`,
+		`        - name`,
+		`        - traceID`,
+	))
+
+	renderer := NewOpenAPIRenderer("/test/path", nil)
+	renderer.EmitInternalExtension = true
+	gotStrings, _ = renderer.ProcessResult(schema)
+	compareStrings(t, "internal: extension", gotStrings, wantStrings(
+		`        traceID:`,
+		`          x-internal: true`,
+		`          type: string`,
+		`      required:`,
+		`        - name`,
+		`        - traceID`,
+	))
+
+	renderer = NewOpenAPIRenderer("/test/path", nil)
+	renderer.ExcludeInternal = true
+	gotStrings, _ = renderer.ProcessResult(schema)
+	compareStrings(t, "internal: exclude", gotStrings, wantStrings(
+		`      required:`,
+		`        - name`,
+	))
+}
+
+// OmitErrorsStruct has fields with and without errors.
+type OmitErrorsStruct struct {
+	Name   string
+	Events chan int
+	Nested struct {
+		Count    int
+		Callback func()
+	}
+}
+
+func TestRenderer_OmitErrors(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(OmitErrorsStruct{})
+
+	opt := NewOptions()
+	opt.DeReference = true
+	opt.OmitErrors = true
+
+	gotStrings, _ := NewSimpleRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "omit errors: simple", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.Name:string`,
+		`Root.{}.Nested:{}`,
+		`Root.{}.Nested:{}.Count:integer`,
+	})
+
+	gotStrings, _ = NewJTDRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "omit errors: jtd", gotStrings, []string{
+		`{`,
+		`  "properties": {`,
+		`    "Name": {`,
+		`      "type": "string"`,
+		`    },`,
+		`    "Nested": {`,
+		`      "properties": {`,
+		`        "Count": {`,
+		`          "metadata": {`,
+		`            "note": "int is rendered as int32 because JTD has no 64-bit integer type"`,
+		`          },`,
+		`          "type": "int32"`,
+		`        }`,
+		`      }`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+
+	// Omitted errors are not reported by any renderer.
+	for _, name := range []string{"go", "json", "jsonschema", "jtd", "markdown", "openapi", "proto", "simple"} {
+		for _, omitErrors := range []bool{false, true} {
+			opt := NewOptions()
+			opt.OmitErrors = omitErrors
+
+			renderer, _ := NewRenderer(name, opt)
+			if _, err := renderer.ProcessResult(schema); (err == nil) != omitErrors {
+				t.Errorf("TEST_FAIL omit errors: %s: OmitErrors=%t: got err=%v", name, omitErrors, err)
+			}
+		}
+	}
+
+	// Cyclical references are kept.
+	r = reflector.NewReflector()
+	gotStrings, _ = NewSimpleRenderer(opt).ProcessResult(r.DeriveSchema(TreeStruct{}))
+	compareStrings(t, "omit errors: cycle", gotStrings, []string{
+		`Root.{}`,
+		`Root.{}.Children:[]`,
+		`Root.{}.Children:[].!{}:TreeStruct! ERROR:cyclical reference`,
+		`Root.{}.Index:{}`,
+		`Root.{}.Index:{}.!{}:TreeStruct! ERROR:cyclical reference`,
+		`Root.{}.Name:string`,
+	})
+}
+
+func TestRenderer_ProcessDocument(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(StatusEnumStruct{})
+
+	gotDoc, err := NewOpenAPIRenderer("/test/path", nil).ProcessDocument(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL document: openapi: unexpected error: %s", err)
+	}
+	gotJSON, _ := json.MarshalIndent(gotDoc, "", "  ")
+	compareStrings(t, "document: openapi", strings.Split(string(gotJSON), "\n"), []string{
+		`{`,
+		`  "components": {`,
+		`    "schemas": {`,
+		`      "StatusEnum": {`,
+		`        "enum": [`,
+		`          "active",`,
+		`          "inactive",`,
+		`          "pending"`,
+		`        ],`,
+		`        "type": "string"`,
+		`      },`,
+		`      "StatusEnumStruct": {`,
+		`        "properties": {`,
+		`          "status": {`,
+		`            "allOf": [`,
+		`              {`,
+		`                "$ref": "#/components/schemas/StatusEnum"`,
+		`              }`,
+		`            ],`,
+		`            "default": "pending"`,
+		`          }`,
+		`        },`,
+		`        "required": [`,
+		`          "status"`,
+		`        ],`,
+		`        "type": "object"`,
+		`      }`,
+		`    }`,
+		`  },`,
+		`  "openapi": "3.0.0",`,
+		`  "paths": {`,
+		`    "/test/path": {`,
+		`      "get": {`,
+		`        "responses": {`,
+		`          "200": {`,
+		`            "content": {`,
+		`              "application/json": {`,
+		`                "schema": {`,
+		`                  "$ref": "#/components/schemas/StatusEnumStruct"`,
+		`                }`,
+		`              }`,
+		`            },`,
+		`            "description": "Success"`,
+		`          }`,
+		`        },`,
+		`        "summary": "Return data."`,
+		`      }`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+
+	// Query parameters are a list of maps.
+	r = reflector.NewReflector()
+	render := NewOpenAPIRenderer("/test/path", nil)
+	render.QueryParams = true
+	gotDoc, _ = render.ProcessDocument(r.DeriveSchema(QueryParamStruct{}))
+	gotJSON, _ = json.MarshalIndent(gotDoc["paths"], "", "  ")
+	compareStrings(t, "document: query params", strings.Split(string(gotJSON), "\n"), []string{
+		`{`,
+		`  "/test/path": {`,
+		`    "get": {`,
+		`      "parameters": [`,
+		`        {`,
+		`          "error": "query parameter must be a basic type",`,
+		`          "in": "query",`,
+		`          "name": "Filter"`,
+		`        },`,
+		`        {`,
+		`          "in": "query",`,
+		`          "name": "limit",`,
+		`          "schema": {`,
+		`            "type": "integer"`,
+		`          }`,
+		`        },`,
+		`        {`,
+		`          "in": "query",`,
+		`          "name": "q",`,
+		`          "required": true,`,
+		`          "schema": {`,
+		`            "type": "string"`,
+		`          }`,
+		`        },`,
+		`        {`,
+		`          "in": "query",`,
+		`          "name": "since",`,
+		`          "schema": {`,
+		`            "format": "date-time",`,
+		`            "type": "string"`,
+		`          }`,
+		`        },`,
+		`        {`,
+		`          "error": "query parameter must be a basic type",`,
+		`          "in": "query",`,
+		`          "name": "tags"`,
+		`        }`,
+		`      ],`,
+		`      "responses": {`,
+		`        "200": {`,
+		`          "description": "Success"`,
+		`        }`,
+		`      },`,
+		`      "summary": "Return data."`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+
+	r = reflector.NewReflector()
+	schema = r.DeriveSchema(StatusEnumStruct{})
+	gotDoc, err = NewJTDRenderer(nil).ProcessDocument(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL document: jtd: unexpected error: %s", err)
+	}
+	gotJSON, _ = json.MarshalIndent(gotDoc, "", "  ")
+	compareStrings(t, "document: jtd", strings.Split(string(gotJSON), "\n"), []string{
+		`{`,
+		`  "definitions": {`,
+		`    "StatusEnum": {`,
+		`      "type": "string"`,
+		`    },`,
+		`    "StatusEnumStruct": {`,
+		`      "properties": {`,
+		`        "status": {`,
+		`          "ref": "StatusEnum"`,
+		`        }`,
+		`      }`,
+		`    }`,
+		`  },`,
+		`  "ref": "StatusEnumStruct"`,
+		`}`,
+	})
+}
+
+func TestOpenAPIRenderer_RequestBody(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(StringStruct{})
+
+	renderer := NewOpenAPIRenderer("/test/path", nil)
+	renderer.Method = "post"
+	renderer.RequestBody = true
+	gotStrings, _ := renderer.ProcessResult(schema)
+	compareStrings(t, "request-body: refs", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    StringStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Value:`,
+		`          type: string`,
+		`      required:`,
+		`        - Value`,
+		`paths:`,
+		`  /test/path:`,
+		`    post:`,
+		`      summary: Accept data.`,
+		`      requestBody:`,
+		`        required: true`,
+		`        content:`,
+		`          application/json:`,
+		`            schema:`,
+		`              $ref: '#/components/schemas/StringStruct'`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+	})
+
+	opt := NewOptions()
+	opt.DeReference = true
+	renderer = NewOpenAPIRenderer("/test/path", opt)
+	renderer.Method = "PUT"
+	renderer.RequestBody = true
+	renderer.EmitExampleRequest = true
+	gotStrings, _ = renderer.ProcessResult(schema)
+	compareStrings(t, "request-body: de-reference", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    put:`,
+		`      summary: Accept data.`,
+		`      requestBody:`,
+		`        required: true`,
+		`        content:`,
+		`          application/json:`,
+		`            schema:`,
+		`              type: object`,
+		`              properties:`,
+		`                Value:`,
+		`                  type: string`,
+		`              required:`,
+		`                - Value`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`# Example request:`,
+		`# PUT /test/path`,
+		`# {`,
+		`#   "Value": "string"`,
+		`# }`,
+	})
+}
+
+// GoNameStruct has fields with and without renamed JSON names.
+type GoNameStruct struct {
+	UserID string            `json:"user_id"`
+	Name   string            `json:"Name"`
+	Labels map[string]string `json:"labels"`
+}
+
+func TestOpenAPIRenderer_EmitGoName(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(GoNameStruct{})
+
+	render := NewOpenAPIRenderer("/test/path", nil)
+	render.EmitGoName = true
+
+	gotStrings, _ := render.ProcessResult(schema)
+	compareStrings(t, "emit-go-name", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    GoNameStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        labels:`,
+		`          x-go-name: Labels`,
+		`          type: object`,
+		`          additionalProperties:`,
+		`            type: string`,
+		`        Name:`,
+		`          type: string`,
+		`        user_id:`,
+		`          x-go-name: UserID`,
+		`          type: string`,
+		`      required:`,
+		`        - Name`,
+		`        - labels`,
+		`        - user_id`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/GoNameStruct'`,
+	})
+}
+
+func TestNewRenderer(t *testing.T) {
+	if _, err := NewRenderer("unknown", nil); err == nil {
+		t.Errorf("TEST_FAIL new renderer: unknown renderer: got nil error")
+	}
+
+	// Registered renderers are available by name.
+	RegisterRenderer("test", func(opt *Options) Renderer { return NewJTDRenderer(opt) })
+	defer RegisterRenderer("test", nil)
+
+	render, err := NewRenderer("test", nil)
+	if err != nil {
+		t.Fatalf("TEST_FAIL new renderer: registered: unexpected error: %s", err)
+	}
+
+	schema := reflector.NewReflector().DeriveSchema(StringStruct{})
+	gotStrings, _ := render.ProcessResult(schema)
+	wantStrings, _ := NewJTDRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "new renderer: registered", gotStrings, wantStrings)
+}
+
+// SimpleMapStruct has a named map field.
+type SimpleMapStruct struct {
+	Counts SimpleMap `json:"counts"`
+}
+
+func TestOpenAPIRenderer_NamedMap(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(SimpleMapStruct{})
+
+	// Named maps are references to a TypeRef with additionalProperties.
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	compareStrings(t, "named-map: refs", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    SimpleMap:`,
+		`      type: object`,
+		`      additionalProperties:`,
+		`        type: integer`,
+		`        format: int64`,
+		`    SimpleMapStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        counts:`,
+		`          $ref: '#/components/schemas/SimpleMap'`,
+		`      required:`,
+		`        - counts`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/SimpleMapStruct'`,
+	})
+
+	// De-referenced named maps are inline objects.
+	opt := NewOptions()
+	opt.DeReference = true
+	gotStrings, _ = NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "named-map: de-reference", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  counts:`,
+		`                    type: object`,
+		`                    additionalProperties:`,
+		`                      type: integer`,
+		`                      format: int64`,
+		`                required:`,
+		`                  - counts`,
+	})
+}
+
+// ErrorEnvelope is an error response.
+type ErrorEnvelope struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestOpenAPIRenderer_AddResponse(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(StringStruct{})
+	errorSchema := reflector.NewReflector().DeriveSchema(ErrorEnvelope{})
+
+	renderer := NewOpenAPIRenderer("/test/path", nil)
+	renderer.AddResponse(404, errorSchema)
+	renderer.AddResponse(400, errorSchema)
+	gotStrings, _ := renderer.ProcessResult(schema)
+	compareStrings(t, "add-response: refs", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    ErrorEnvelope:`,
+		`      type: object`,
+		`      properties:`,
+		`        code:`,
+		`          type: integer`,
+		`        message:`,
+		`          type: string`,
+		`      required:`,
+		`        - code`,
+		`        - message`,
+		`    StringStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Value:`,
+		`          type: string`,
+		`      required:`,
+		`        - Value`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/StringStruct'`,
+		`        "400":`,
+		`          description: Bad Request`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/ErrorEnvelope'`,
+		`        "404":`,
+		`          description: Not Found`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/ErrorEnvelope'`,
+	})
+
+	opt := NewOptions()
+	opt.DeReference = true
+	renderer = NewOpenAPIRenderer("/test/path", opt)
+	renderer.Method = "post"
+	renderer.RequestBody = true
+	renderer.AddResponse(400, errorSchema)
+	gotStrings, _ = renderer.ProcessResult(schema)
+	compareStrings(t, "add-response: request body", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    post:`,
+		`      summary: Accept data.`,
+		`      requestBody:`,
+		`        required: true`,
+		`        content:`,
+		`          application/json:`,
+		`            schema:`,
+		`              type: object`,
+		`              properties:`,
+		`                Value:`,
+		`                  type: string`,
+		`              required:`,
+		`                - Value`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`        "400":`,
+		`          description: Bad Request`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  code:`,
+		`                    type: integer`,
+		`                  message:`,
+		`                    type: string`,
+		`                required:`,
+		`                  - code`,
+		`                  - message`,
+	})
+}
+
+func TestSchema_Hash(t *testing.T) {
+	hashOf := func(x interface{}) string {
+		return reflector.NewReflector().DeriveSchema(x).Hash()
+	}
+
+	got := hashOf(TreeStruct{})
+	if len(got) != 64 {
+		t.Errorf("TEST_FAIL hash: got length %d, want 64", len(got))
+	}
+
+	// The same type has the same hash regardless of values.
+	if got2 := hashOf(TreeStruct{Name: "root", Children: []TreeStruct{{Name: "child"}}}); got2 != got {
+		t.Errorf("TEST_FAIL hash: same type: got %s, want %s", got2, got)
+	}
+
+	// Different types have different hashes.
+	for _, x := range []interface{}{StringStruct{}, GoNameStruct{}, StatusEnumStruct{}, BadStatusEnumStruct{}} {
+		if other := hashOf(x); other == got {
+			t.Errorf("TEST_FAIL hash: %T: got same hash as TreeStruct", x)
+		}
+	}
+}
+
+// ChainItem is the leaf of nested reference chains.
+type ChainItem struct {
+	Name string `json:"name"`
+}
+
+// ChainStruct has nested pointer, list and map chains.
+type ChainStruct struct {
+	Lists    *[]map[string]*ChainItem `json:"lists"`
+	Maps     []*map[string]ChainItem  `json:"maps"`
+	ItemPtrs map[string][]*ChainItem  `json:"itemPtrs"`
+}
+
+func TestOpenAPIRenderer_ReferenceChains(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(ChainStruct{})
+
+	renderer := NewOpenAPIRenderer("/test/path", nil)
+	renderer.NullableKeyword = NullableOpenAPI3
+	gotStrings, _ := renderer.ProcessResult(schema)
+	compareStrings(t, "reference-chains", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    ChainItem:`,
+		`      type: object`,
+		`      properties:`,
+		`        name:`,
+		`          type: string`,
+		`      required:`,
+		`        - name`,
+		`    ChainStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        itemPtrs:`,
+		`          type: object`,
+		`          additionalProperties:`,
+		`            type: array`,
+		`            items:`,
+		`              nullable: true`,
+		`              allOf:`,
+		`                - $ref: '#/components/schemas/ChainItem'`,
+		`        lists:`,
+		`          nullable: true`,
+		`          type: array`,
+		`          items:`,
+		`            type: object`,
+		`            additionalProperties:`,
+		`              nullable: true`,
+		`              allOf:`,
+		`                - $ref: '#/components/schemas/ChainItem'`,
+		`        maps:`,
+		`          type: array`,
+		`          items:`,
+		`            nullable: true`,
+		`            type: object`,
+		`            additionalProperties:`,
+		`              $ref: '#/components/schemas/ChainItem'`,
+		`      required:`,
+		`        - itemPtrs`,
+		`        - maps`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/ChainStruct'`,
+	})
+	// De-referenced chains keep nullability on the same elements.
+	opt := NewOptions()
+	opt.DeReference = true
+	renderer = NewOpenAPIRenderer("/test/path", opt)
+	renderer.NullableKeyword = NullableOpenAPI3
+	gotStrings, _ = renderer.ProcessResult(schema)
+	compareStrings(t, "reference-chains: de-reference", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  itemPtrs:`,
+		`                    type: object`,
+		`                    additionalProperties:`,
+		`                      type: array`,
+		`                      items:`,
+		`                        nullable: true`,
+		`                        type: object`,
+		`                        properties:`,
+		`                          name:`,
+		`                            type: string`,
+		`                        required:`,
+		`                          - name`,
+		`                  lists:`,
+		`                    nullable: true`,
+		`                    type: array`,
+		`                    items:`,
+		`                      type: object`,
+		`                      additionalProperties:`,
+		`                        nullable: true`,
+		`                        type: object`,
+		`                        properties:`,
+		`                          name:`,
+		`                            type: string`,
+		`                        required:`,
+		`                          - name`,
+		`                  maps:`,
+		`                    type: array`,
+		`                    items:`,
+		`                      nullable: true`,
+		`                      type: object`,
+		`                      additionalProperties:`,
+		`                        type: object`,
+		`                        properties:`,
+		`                          name:`,
+		`                            type: string`,
+		`                        required:`,
+		`                          - name`,
+		`                required:`,
+		`                  - itemPtrs`,
+		`                  - maps`,
+	})
+}
+
+func TestRenderer_Banner(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(StringStruct{})
+
+	opt := NewOptions()
+	opt.Banner = "Code generated by b9schema. DO NOT EDIT.\n\nSource: StringStruct"
+
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "banner: openapi", gotStrings[:4], []string{
+		`# Code generated by b9schema. DO NOT EDIT.`,
+		`#`,
+		`# Source: StringStruct`,
+		`openapi: 3.0.0`,
+	})
+
+	gotStrings, _ = NewSimpleRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "banner: simple", gotStrings, []string{
+		`# Code generated by b9schema. DO NOT EDIT.`,
+		`#`,
+		`# Source: StringStruct`,
+		`TypeRefs.StringStruct:{}`,
+		`TypeRefs.StringStruct:{}.Value:string`,
+		`Root.{}:StringStruct`,
+	})
+
+	// JSON has no comments.
+	gotStrings, _ = NewJTDRenderer(opt).ProcessResult(schema)
+	if gotStrings[0] != `{` {
+		t.Errorf("TEST_FAIL banner: jtd: got first line %q, want %q", gotStrings[0], `{`)
+	}
+}
+
+func TestOpenAPIRenderer_AnonymousStruct(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(OtherEntity{})
+
+	// Anonymous structs are inline objects. Only the AnonStruct property is checked.
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+
+	start := -1
+	for i, line := range gotStrings {
+		if line == `        AnonStruct:` {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		t.Fatalf("TEST_FAIL anonymous-struct: AnonStruct not found")
+	}
+
+	compareStrings(t, "anonymous-struct", gotStrings[start:start+11], []string{
+		`        AnonStruct:`,
+		`          type: object`,
+		`          properties:`,
+		`            FieldOne:`,
+		`              type: string`,
+		`            FieldThree:`,
+		`              type: number`,
+		`              format: float`,
+		`            FieldTwo:`,
+		`              type: integer`,
+		`              format: int32`,
+	})
+}
+
+// AnyStruct has an interface field for arbitrary JSON.
+type AnyStruct struct {
+	Name  string      `json:"name"`
+	Extra interface{} `json:"extra"`
+}
+
+func TestOpenAPIRenderer_AnyForNilInterface(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(AnyStruct{})
+
+	opt := NewOptions()
+	opt.DeReference = true
+
+	// Default is an error.
+	gotStrings, err := NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	if err == nil {
+		t.Errorf("TEST_FAIL any: default: got nil error")
+	}
+	compareStrings(t, "any: default", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  extra:`,
+		`                    type: invalid`,
+		`                    error: interface element is nil`,
+		`                  name:`,
+		`                    type: string`,
+		`                required:`,
+		`                  - name`,
+	})
+
+	renderer := NewOpenAPIRenderer("/test/path", opt)
+	renderer.AnyForNilInterface = true
+	gotStrings, err = renderer.ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL any: unexpected error: %s", err)
+	}
+	compareStrings(t, "any: untyped", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  extra: {}`,
+		`                  name:`,
+		`                    type: string`,
+		`                required:`,
+		`                  - name`,
+	})
+
+	// Debug renderers keep the error.
+	if _, err := NewSimpleRenderer(opt).ProcessResult(schema); err == nil {
+		t.Errorf("TEST_FAIL any: simple: got nil error")
+	}
+}
+
+func TestJSONSchemaRenderer_AnyForNilInterface(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(AnyStruct{})
+
+	opt := NewOptions()
+	opt.DeReference = true
+
+	// Default is an error.
+	gotStrings, err := NewJSONSchemaRenderer(opt).ProcessResult(schema)
+	if err == nil {
+		t.Errorf("TEST_FAIL any: default: got nil error")
+	}
+	compareStrings(t, "any: default", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "extra": {`,
+		`      "$comment": "error: interface element is nil"`,
+		`    },`,
+		`    "name": {`,
+		`      "type": "string"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "name"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+
+	renderer := NewJSONSchemaRenderer(opt)
+	renderer.AnyForNilInterface = true
+	gotStrings, err = renderer.ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL any: unexpected error: %s", err)
+	}
+	compareStrings(t, "any: empty schema", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "extra": {},`,
+		`    "name": {`,
+		`      "type": "string"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "name"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+}
+
+func TestJSONSchemaRenderer_SpecialTypes(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(SpecialTypes{})
+
+	opt := NewOptions()
+	opt.DeReference = true
+
+	gotStrings, err := NewJSONSchemaRenderer(opt).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL special: unexpected error: %s", err)
+	}
+	compareStrings(t, "special", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "DateTime": {`,
+		`      "format": "date-time",`,
+		`      "type": "string"`,
+		`    },`,
+		`    "Duration": {`,
+		`      "format": "duration",`,
+		`      "type": "string"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "DateTime",`,
+		`    "Duration"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+}
+
+// ConfigStruct is a config struct with mapstructure tags.
+type ConfigStruct struct {
+	ListenAddr string                 `mapstructure:"listen_addr" json:"listenAddr"`
+	Timeout    int                    `mapstructure:"timeout,omitempty"`
+	Secret     string                 `mapstructure:"-"`
+	Extra      map[string]interface{} `mapstructure:",remain" json:"-"`
+}
+
+func TestRenderer_MapstructureDialect(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(ConfigStruct{})
+	if err := SchemaErrors(schema); err != nil {
+		t.Errorf("TEST_FAIL mapstructure: unexpected error: %s", err)
+	}
+
+	opt := NewOptions()
+	opt.DeReference = true
+	opt.Dialect = "mapstructure"
+
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "mapstructure: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  listen_addr:`,
+		`                    type: string`,
+		`                  timeout:`,
+		`                    type: integer`,
+		`                required:`,
+		`                  - listen_addr`,
+		`                additionalProperties: true`,
+	})
+
+	gotStrings, _ = NewJTDRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "mapstructure: jtd", gotStrings, []string{
+		`{`,
+		`  "additionalProperties": true,`,
+		`  "optionalProperties": {`,
+		`    "timeout": {`,
+		`      "metadata": {`,
+		`        "note": "int is rendered as int32 because JTD has no 64-bit integer type"`,
+		`      },`,
+		`      "type": "int32"`,
+		`    }`,
+		`  },`,
+		`  "properties": {`,
+		`    "listen_addr": {`,
+		`      "type": "string"`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+
+	// The json dialect is unchanged.
+	opt = NewOptions()
+	opt.DeReference = true
+	gotStrings, _ = NewJTDRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "mapstructure: json dialect", gotStrings, []string{
+		`{`,
+		`  "properties": {`,
+		`    "Secret": {`,
+		`      "type": "string"`,
+		`    },`,
+		`    "Timeout": {`,
+		`      "metadata": {`,
+		`        "note": "int is rendered as int32 because JTD has no 64-bit integer type"`,
+		`      },`,
+		`      "type": "int32"`,
+		`    },`,
+		`    "listenAddr": {`,
+		`      "type": "string"`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+}
+
+func TestSchema_StripNative(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(GoNameStruct{})
+	stripped := schema.StripNative("json")
+
+	for _, childElem := range stripped.TypeRefs.ChildByName("GoNameStruct", nil).Children {
+		if _, ok := childElem.Native[reflector.NATIVE_DIALECT]; ok {
+			t.Errorf("TEST_FAIL strip-native: %s: golang native type not removed", childElem.Name)
+		}
+		if _, ok := childElem.Native["json"]; !ok {
+			t.Errorf("TEST_FAIL strip-native: %s: json native type removed", childElem.Name)
+		}
+	}
+
+	// The original schema is not changed.
+	if _, ok := schema.TypeRefs.ChildByName("GoNameStruct", nil).Children[0].Native[reflector.NATIVE_DIALECT]; !ok {
+		t.Errorf("TEST_FAIL strip-native: original schema changed")
+	}
+
+	// JSON names are kept so the rendered schema is the same.
+	wantStrings, _ := NewJTDRenderer(nil).ProcessResult(schema)
+	gotStrings, _ := NewJTDRenderer(nil).ProcessResult(stripped)
+	compareStrings(t, "strip-native: jtd", gotStrings, wantStrings)
+}
+
+func TestSchema_JSON(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(CycleTest{})
+
+	b, err := schema.ToJSON()
+	if err != nil {
+		t.Fatalf("TEST_FAIL schema-json: ToJSON: %s", err)
+	}
+	loaded, err := types.SchemaFromJSON(b)
+	if err != nil {
+		t.Fatalf("TEST_FAIL schema-json: SchemaFromJSON: %s", err)
+	}
+
+	// Reloaded schemas render the same as reflected schemas.
+	wantStrings, _ := NewSimpleRenderer(nil).ProcessResult(schema)
+	gotStrings, _ := NewSimpleRenderer(nil).ProcessResult(loaded)
+	compareStrings(t, "schema-json: simple", gotStrings, wantStrings)
+
+	wantStrings, _ = NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	gotStrings, _ = NewOpenAPIRenderer("/test/path", nil).ProcessResult(loaded)
+	compareStrings(t, "schema-json: openapi", gotStrings, wantStrings)
+
+	if loaded.Hash() != schema.Hash() {
+		t.Errorf("TEST_FAIL schema-json: hash differs")
+	}
+
+	// Parents are restored.
+	var checkParents func(elem *types.TypeElement)
+	checkParents = func(elem *types.TypeElement) {
+		for _, childElem := range elem.Children {
+			if childElem.Parent != elem {
+				t.Errorf("TEST_FAIL schema-json: %s: parent not restored", types.ElementPath(childElem))
+			}
+			checkParents(childElem)
+		}
+	}
+	checkParents(loaded.Root)
+	checkParents(loaded.TypeRefs)
+
+	if _, err := types.SchemaFromJSON([]byte(`{}`)); err == nil {
+		t.Errorf("TEST_FAIL schema-json: missing root: got no error")
+	}
+}
+
+func TestDiffSchemas(t *testing.T) {
+	oldSchema := reflector.NewReflector().DeriveSchema(BasicStruct{})
+
+	// Same schema has no changes.
+	if changes := types.DiffSchemas(oldSchema, reflector.NewReflector().DeriveSchema(BasicStruct{})); len(changes) != 0 {
+		t.Errorf("TEST_FAIL diff-schemas: same: got %d changes, want 0", len(changes))
+	}
+
+	// BasicStruct with a removed, a changed, a renamed and an added field.
+	type BasicStruct struct {
+		BoolVal    bool
+		IntVal     string
+		Float64Val float64 `json:"float64Val"`
+		NewVal     int
+	}
+	newSchema := reflector.NewReflector().DeriveSchema(BasicStruct{})
+
+	gotStrings := []string{}
+	for _, change := range types.DiffSchemas(oldSchema, newSchema) {
+		gotStrings = append(gotStrings, fmt.Sprintf("%s %s %q %q %q %q", change.Kind, change.Path, change.OldType, change.NewType, change.OldName, change.NewName))
+	}
+	compareStrings(t, "diff-schemas: changed", gotStrings, []string{
+		`renamed Root.{}.Float64Val "float" "float" "Float64Val" "float64Val"`,
+		`typeChanged Root.{}.IntVal "integer" "string" "" ""`,
+		`added Root.{}.NewVal "" "integer" "" ""`,
+		`removed Root.{}.StringVal "string" "" "" ""`,
+		`renamed TypeRefs.BasicStruct.Float64Val "float" "float" "Float64Val" "float64Val"`,
+		`typeChanged TypeRefs.BasicStruct.IntVal "integer" "string" "" ""`,
+		`added TypeRefs.BasicStruct.NewVal "" "integer" "" ""`,
+		`removed TypeRefs.BasicStruct.StringVal "string" "" "" ""`,
+	})
+}
+
+// TimePtrCollectionStruct has optional timestamps in lists and maps.
+type TimePtrCollectionStruct struct {
+	Events   []*time.Time          `json:"events"`
+	ByName   map[string]*time.Time `json:"byName"`
+	Optional *[]time.Time          `json:"optional"`
+}
+
+func TestOpenAPIRenderer_TimePtrCollections(t *testing.T) {
+	now := time.Now()
+
+	values := []interface{}{
+		TimePtrCollectionStruct{},
+		TimePtrCollectionStruct{
+			Events: []*time.Time{nil, &now},
+			ByName: map[string]*time.Time{"created": &now, "deleted": nil},
+		},
+	}
+
+	for _, value := range values {
+		schema := reflector.NewReflector().DeriveSchema(value)
+
+		// Nullability is on the item and value elements, not the list and map.
+		opt := NewOptions()
+		opt.DeReference = true
+		renderer := NewOpenAPIRenderer("/test/path", opt)
+		renderer.NullableKeyword = NullableOpenAPI3
+		gotStrings, _ := renderer.ProcessResult(schema)
+		compareStrings(t, "time-ptr-collections", gotStrings, []string{
+			`openapi: 3.0.0`,
+			`paths:`,
+			`  /test/path:`,
+			`    get:`,
+			`      summary: Return data.`,
+			`      responses:`,
+			`        "200":`,
+			`          description: Success`,
+			`          content:`,
+			`            application/json:`,
+			`              schema:`,
+			`                type: object`,
+			`                properties:`,
+			`                  byName:`,
+			`                    type: object`,
+			`                    additionalProperties:`,
+			`                      nullable: true`,
+			`                      type: string`,
+			`                      format: date-time`,
+			`                  events:`,
+			`                    type: array`,
+			`                    items:`,
+			`                      nullable: true`,
+			`                      type: string`,
+			`                      format: date-time`,
+			`                  optional:`,
+			`                    nullable: true`,
+			`                    type: array`,
+			`                    items:`,
+			`                      type: string`,
+			`                      format: date-time`,
+			`                required:`,
+			`                  - byName`,
+			`                  - events`,
+		})
+	}
+}
+
+// JSONSchemaStruct has basic, time, list, pointer and reference fields.
+type JSONSchemaStruct struct {
+	Name    string     `json:"name"`
+	Count   int        `json:"count,omitempty"`
+	Score   float64    `json:"score"`
+	Active  bool       `json:"active"`
+	Created time.Time  `json:"created"`
+	Tags    []string   `json:"tags"`
+	Parent  *AStruct   `json:"parent"`
+	Updated *time.Time `json:"updated"`
+}
+
+func TestJSONSchemaRenderer(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(JSONSchemaStruct{})
+
+	gotStrings, err := NewJSONSchemaRenderer(nil).ProcessResult(schema)
+	if err != nil {
+		t.Errorf("TEST_FAIL json-schema: unexpected error: %s", err)
+	}
+
+	// Output must be valid JSON.
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(strings.Join(gotStrings, "\n")), &doc); err != nil {
+		t.Errorf("TEST_FAIL json-schema: invalid JSON: %s", err)
+	}
+
+	compareStrings(t, "json-schema: refs", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "allOf": [`,
+		`    {`,
+		`      "$ref": "#/definitions/JSONSchemaStruct"`,
+		`    }`,
+		`  ],`,
+		`  "definitions": {`,
+		`    "AStruct": {`,
+		`      "properties": {`,
+		`        "aChild": {`,
+		`          "anyOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/BStruct"`,
+		`            },`,
+		`            {`,
+		`              "type": "null"`,
+		`            }`,
+		`          ]`,
+		`        },`,
+		`        "aName": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "BStruct": {`,
+		`      "properties": {`,
+		`        "bChild": {`,
+		`          "anyOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/CStruct"`,
+		`            },`,
+		`            {`,
+		`              "type": "null"`,
+		`            }`,
+		`          ]`,
+		`        },`,
+		`        "bName": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "bName"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    },`,
+		`    "CStruct": {`,
+		`      "properties": {`,
+		`        "cChild": {`,
+		`          "anyOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/AStruct"`,
+		`            },`,
+		`            {`,
+		`              "type": "null"`,
+		`            }`,
+		`          ]`,
+		`        },`,
+		`        "cName": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "cName"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    },`,
+		`    "JSONSchemaStruct": {`,
+		`      "properties": {`,
+		`        "active": {`,
+		`          "type": "boolean"`,
+		`        },`,
+		`        "count": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "created": {`,
+		`          "format": "date-time",`,
+		`          "type": "string"`,
+		`        },`,
+		`        "name": {`,
+		`          "type": "string"`,
+		`        },`,
+		`        "parent": {`,
+		`          "anyOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/AStruct"`,
+		`            },`,
+		`            {`,
+		`              "type": "null"`,
+		`            }`,
+		`          ]`,
+		`        },`,
+		`        "score": {`,
+		`          "type": "number"`,
+		`        },`,
+		`        "tags": {`,
+		`          "items": {`,
+		`            "type": "string"`,
+		`          },`,
+		`          "type": "array"`,
+		`        },`,
+		`        "updated": {`,
+		`          "format": "date-time",`,
+		`          "type": [`,
+		`            "string",`,
+		`            "null"`,
+		`          ]`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "active",`,
+		`        "created",`,
+		`        "name",`,
+		`        "score",`,
+		`        "tags"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+
+	// De-referenced schemas have no definitions.
+	opt := NewOptions()
+	opt.DeReference = true
+	gotStrings, _ = NewJSONSchemaRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "json-schema: de-reference", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "definitions": {`,
+		`    "AStruct": {`,
+		`      "properties": {`,
+		`        "aChild": {`,
+		`          "anyOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/BStruct"`,
+		`            },`,
+		`            {`,
+		`              "type": "null"`,
+		`            }`,
+		`          ]`,
+		`        },`,
+		`        "aName": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "BStruct": {`,
+		`      "properties": {`,
+		`        "bChild": {`,
+		`          "anyOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/CStruct"`,
+		`            },`,
+		`            {`,
+		`              "type": "null"`,
+		`            }`,
+		`          ]`,
+		`        },`,
+		`        "bName": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "bName"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    },`,
+		`    "CStruct": {`,
+		`      "properties": {`,
+		`        "cChild": {`,
+		`          "anyOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/AStruct"`,
+		`            },`,
+		`            {`,
+		`              "type": "null"`,
+		`            }`,
+		`          ]`,
+		`        },`,
+		`        "cName": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "cName"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    },`,
+		`    "JSONSchemaStruct": {`,
+		`      "properties": {`,
+		`        "active": {`,
+		`          "type": "boolean"`,
+		`        },`,
+		`        "count": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "created": {`,
+		`          "format": "date-time",`,
+		`          "type": "string"`,
+		`        },`,
+		`        "name": {`,
+		`          "type": "string"`,
+		`        },`,
+		`        "parent": {`,
+		`          "anyOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/AStruct"`,
+		`            },`,
+		`            {`,
+		`              "type": "null"`,
+		`            }`,
+		`          ]`,
+		`        },`,
+		`        "score": {`,
+		`          "type": "number"`,
+		`        },`,
+		`        "tags": {`,
+		`          "items": {`,
+		`            "type": "string"`,
+		`          },`,
+		`          "type": "array"`,
+		`        },`,
+		`        "updated": {`,
+		`          "format": "date-time",`,
+		`          "type": [`,
+		`            "string",`,
+		`            "null"`,
+		`          ]`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "active",`,
+		`        "created",`,
+		`        "name",`,
+		`        "score",`,
+		`        "tags"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    }`,
+		`  },`,
+		`  "properties": {`,
+		`    "active": {`,
+		`      "type": "boolean"`,
+		`    },`,
+		`    "count": {`,
+		`      "type": "integer"`,
+		`    },`,
+		`    "created": {`,
+		`      "format": "date-time",`,
+		`      "type": "string"`,
+		`    },`,
+		`    "name": {`,
+		`      "type": "string"`,
+		`    },`,
+		`    "parent": {`,
+		`      "properties": {`,
+		`        "aChild": {`,
+		`          "properties": {`,
+		`            "bChild": {`,
+		`              "properties": {`,
+		`                "cChild": {`,
+		`                  "anyOf": [`,
+		`                    {`,
+		`                      "$ref": "#/definitions/AStruct"`,
+		`                    },`,
+		`                    {`,
+		`                      "type": "null"`,
+		`                    }`,
+		`                  ]`,
+		`                },`,
+		`                "cName": {`,
+		`                  "type": "string"`,
+		`                }`,
+		`              },`,
+		`              "required": [`,
+		`                "cName"`,
+		`              ],`,
+		`              "type": [`,
+		`                "object",`,
+		`                "null"`,
+		`              ]`,
+		`            },`,
+		`            "bName": {`,
+		`              "type": "string"`,
+		`            }`,
+		`          },`,
+		`          "required": [`,
+		`            "bName"`,
+		`          ],`,
+		`          "type": [`,
+		`            "object",`,
+		`            "null"`,
+		`          ]`,
+		`        },`,
+		`        "aName": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "type": [`,
+		`        "object",`,
+		`        "null"`,
+		`      ]`,
+		`    },`,
+		`    "score": {`,
+		`      "type": "number"`,
+		`    },`,
+		`    "tags": {`,
+		`      "items": {`,
+		`        "type": "string"`,
+		`      },`,
+		`      "type": "array"`,
+		`    },`,
+		`    "updated": {`,
+		`      "format": "date-time",`,
+		`      "type": [`,
+		`        "string",`,
+		`        "null"`,
+		`      ]`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "active",`,
+		`    "created",`,
+		`    "name",`,
+		`    "score",`,
+		`    "tags"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+
+	// Cyclical references are refs to definitions even in de-reference mode.
+	gotStrings, err = NewJSONSchemaRenderer(opt).ProcessResult(reflector.NewReflector().DeriveSchema(TreeStruct{}))
+	if err != nil {
+		t.Errorf("TEST_FAIL json-schema: cycles: unexpected error: %s", err)
+	}
+	compareStrings(t, "json-schema: cycles", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "definitions": {`,
+		`    "TreeStruct": {`,
+		`      "properties": {`,
+		`        "Children": {`,
+		`          "items": {`,
+		`            "$ref": "#/definitions/TreeStruct"`,
+		`          },`,
+		`          "type": "array"`,
+		`        },`,
+		`        "Index": {`,
+		`          "additionalProperties": {`,
+		`            "$ref": "#/definitions/TreeStruct"`,
+		`          },`,
+		`          "type": "object"`,
+		`        },`,
+		`        "Name": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "Children",`,
+		`        "Index",`,
+		`        "Name"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    }`,
+		`  },`,
+		`  "properties": {`,
+		`    "Children": {`,
+		`      "items": {`,
+		`        "$ref": "#/definitions/TreeStruct"`,
+		`      },`,
+		`      "type": "array"`,
+		`    },`,
+		`    "Index": {`,
+		`      "additionalProperties": {`,
+		`        "$ref": "#/definitions/TreeStruct"`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "Name": {`,
+		`      "type": "string"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "Children",`,
+		`    "Index",`,
+		`    "Name"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+}
+
+// NullableRefStruct has nullable basic and reference fields.
+type NullableRefStruct struct {
+	Name  *string       `json:"name"`
+	Child *StringStruct `json:"child"`
+}
+
+func TestOpenAPIRenderer_OpenAPIVersion(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(NullableRefStruct{})
+
+	// OpenAPI 3.0 uses the nullable keyword.
+	renderer := NewOpenAPIRenderer("/test/path", nil)
+	renderer.OpenAPIVersion = OpenAPIVersion30
+	renderer.NullableKeyword = NullableOpenAPI3
+	gotStrings, _ := renderer.ProcessResult(schema)
+	compareStrings(t, "openapi-version: 3.0", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    NullableRefStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        child:`,
+		`          nullable: true`,
+		`          allOf:`,
+		`            - $ref: '#/components/schemas/StringStruct'`,
+		`        name:`,
+		`          nullable: true`,
+		`          type: string`,
+		`    StringStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Value:`,
+		`          type: string`,
+		`      required:`,
+		`        - Value`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/NullableRefStruct'`,
+	})
+
+	// OpenAPI 3.1 uses type arrays and anyOf for references.
+	renderer = NewOpenAPIRenderer("/test/path", nil)
+	renderer.OpenAPIVersion = OpenAPIVersion31
+	gotStrings, _ = renderer.ProcessResult(schema)
+	compareStrings(t, "openapi-version: 3.1", gotStrings, []string{
+		`openapi: 3.1.0`,
+		`components:`,
+		`  schemas:`,
+		`    NullableRefStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        child:`,
+		`          anyOf:`,
+		`            - $ref: '#/components/schemas/StringStruct'`,
+		`            - type: "null"`,
+		`        name:`,
+		`          type: [string, "null"]`,
+		`    StringStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        Value:`,
+		`          type: string`,
+		`      required:`,
+		`        - Value`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/NullableRefStruct'`,
+	})
+
+	// Type arrays are lists in the document model.
+	doc, _ := renderer.ProcessDocument(schema)
+	gotJSON, _ := json.Marshal(doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})["NullableRefStruct"])
+	compareStrings(t, "openapi-version: 3.1 document", []string{string(gotJSON)}, []string{
+		`{"properties":{"child":{"anyOf":[{"$ref":"#/components/schemas/StringStruct"},{"type":"null"}]},"name":{"type":["string","null"]}},"type":"object"}`,
+	})
+}
+
+// PropertyNamesStruct has maps with constrained keys.
+type PropertyNamesStruct struct {
+	ByStatus   map[StatusEnum]int `json:"byStatus"`
+	ByLanguage map[string]string  `json:"byLanguage" keyEnum:"en,fr"`
+	ByCode     map[string]bool    `json:"byCode" keyPattern:"^[A-Z]{3}$"`
+	Free       map[string]float64 `json:"free"`
+}
+
+func TestJSONSchemaRenderer_PropertyNames(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(PropertyNamesStruct{})
+
+	opt := NewOptions()
+	opt.DeReference = true
+
+	renderer := NewJSONSchemaRenderer(opt)
+	renderer.PropertyNames = true
+	gotStrings, _ := renderer.ProcessResult(schema)
+	compareStrings(t, "property-names: enabled", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "byCode": {`,
+		`      "additionalProperties": {`,
+		`        "type": "boolean"`,
+		`      },`,
+		`      "propertyNames": {`,
+		`        "pattern": "^[A-Z]{3}$"`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "byLanguage": {`,
+		`      "additionalProperties": {`,
+		`        "type": "string"`,
+		`      },`,
+		`      "propertyNames": {`,
+		`        "enum": [`,
+		`          "en",`,
+		`          "fr"`,
+		`        ]`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "byStatus": {`,
+		`      "additionalProperties": {`,
+		`        "type": "integer"`,
+		`      },`,
+		`      "propertyNames": {`,
+		`        "enum": [`,
+		`          "active",`,
+		`          "inactive",`,
+		`          "pending"`,
+		`        ]`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "free": {`,
+		`      "additionalProperties": {`,
+		`        "type": "number"`,
+		`      },`,
+		`      "type": "object"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "byCode",`,
+		`    "byLanguage",`,
+		`    "byStatus",`,
+		`    "free"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+
+	// Default is to constrain only key patterns with patternProperties.
+	gotStrings, _ = NewJSONSchemaRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "property-names: disabled", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "byCode": {`,
+		`      "additionalProperties": false,`,
+		`      "patternProperties": {`,
+		`        "^[A-Z]{3}$": {`,
+		`          "type": "boolean"`,
+		`        }`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "byLanguage": {`,
+		`      "additionalProperties": {`,
+		`        "type": "string"`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "byStatus": {`,
+		`      "additionalProperties": {`,
+		`        "type": "integer"`,
+		`      },`,
+		`      "type": "object"`,
+		`    },`,
+		`    "free": {`,
+		`      "additionalProperties": {`,
+		`        "type": "number"`,
+		`      },`,
+		`      "type": "object"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "byCode",`,
+		`    "byLanguage",`,
+		`    "byStatus",`,
+		`    "free"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+}
+
+// Decimal is a struct that is registered as a known type.
+type Decimal struct {
+	Units int64
+	Nanos int32
+}
+
+// KnownDecimalStruct has fields of a registered known type.
+type KnownDecimalStruct struct {
+	Price    Decimal  `json:"price"`
+	Discount *Decimal `json:"discount"`
+}
+
+func TestRenderer_RegisterKnown(t *testing.T) {
+	reflector.RegisterKnown("github.com/gitmann/b9schema-reflector-golang/renderer", "Decimal", "decimal", "dec")
+	schema := reflector.NewReflector().DeriveSchema(KnownDecimalStruct{})
+
+	// Paths use the path default of the known type.
+	gotStrings, _ := NewSimpleRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "register-known: simple", gotStrings, []string{
+		`TypeRefs.KnownDecimalStruct:{}`,
+		`TypeRefs.KnownDecimalStruct:{}.Discount:dec`,
+		`TypeRefs.KnownDecimalStruct:{}.Price:dec`,
+		`Root.{}:KnownDecimalStruct`,
+	})
+
+	// Schemas are strings with the slug as their format.
+	opt := NewOptions()
+	opt.DeReference = true
+	gotStrings, _ = NewJSONSchemaRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "register-known: json schema", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "discount": {`,
+		`      "format": "decimal",`,
+		`      "type": [`,
+		`        "string",`,
+		`        "null"`,
+		`      ]`,
+		`    },`,
+		`    "price": {`,
+		`      "format": "decimal",`,
+		`      "type": "string"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "price"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+}
+
+// RequiredOuter and RequiredInner have different optional fields.
+type RequiredOuter struct {
+	ID       string        `json:"id"`
+	Note     string        `json:"note,omitempty"`
+	Inner    RequiredInner `json:"inner"`
+	InnerOpt RequiredInner `json:"innerOpt,omitempty"`
+}
+
+type RequiredInner struct {
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+func TestRenderer_NestedRequired(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(RequiredOuter{})
+
+	// Each object has its own required list, also when nested objects are de-referenced.
+	opt := NewOptions()
+	opt.DeReference = true
+	gotStrings, _ := NewJSONSchemaRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "nested-required: json schema", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "id": {`,
+		`      "type": "string"`,
+		`    },`,
+		`    "inner": {`,
+		`      "properties": {`,
+		`        "count": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "name": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "count"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    },`,
+		`    "innerOpt": {`,
+		`      "properties": {`,
+		`        "count": {`,
+		`          "type": "integer"`,
+		`        },`,
+		`        "name": {`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "count"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    },`,
+		`    "note": {`,
+		`      "type": "string"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "id",`,
+		`    "inner"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+
+	gotStrings, _ = NewOpenAPIRenderer("/test/path", opt).ProcessResult(schema)
+	compareStrings(t, "nested-required: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                type: object`,
+		`                properties:`,
+		`                  id:`,
+		`                    type: string`,
+		`                  inner:`,
+		`                    type: object`,
+		`                    properties:`,
+		`                      count:`,
+		`                        type: integer`,
+		`                      name:`,
+		`                        type: string`,
+		`                    required:`,
+		`                      - count`,
+		`                  innerOpt:`,
+		`                    type: object`,
+		`                    properties:`,
+		`                      count:`,
+		`                        type: integer`,
+		`                      name:`,
+		`                        type: string`,
+		`                    required:`,
+		`                      - count`,
+		`                  note:`,
+		`                    type: string`,
+		`                required:`,
+		`                  - id`,
+		`                  - inner`,
+	})
+}
+
+// DescribedStruct has descriptions on basic and struct fields.
+type DescribedStruct struct {
+	ID    string         `json:"id" b9schema:"description=Unique ID, assigned by the server."`
+	Owner DescribedOwner `json:"owner" b9schema:"description=Owner of the record."`
+}
+
+type DescribedOwner struct {
+	Name string `json:"name" b9schema:"description=Full name.\nMay span lines."`
+}
+
+func TestRenderer_Description(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(DescribedStruct{})
+
+	// Multi-line descriptions are folded into one line.
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	compareStrings(t, "description: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    DescribedOwner:`,
+		`      type: object`,
+		`      properties:`,
+		`        name:`,
+		`          description: |-`,
+		`            Full name.`,
+		`            May span lines.`,
+		`          type: string`,
+		`      required:`,
+		`        - name`,
+		`    DescribedStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        id:`,
+		`          description: Unique ID, assigned by the server.`,
+		`          type: string`,
+		`        owner:`,
+		`          description: Owner of the record.`,
+		`          allOf:`,
+		`            - $ref: '#/components/schemas/DescribedOwner'`,
+		`      required:`,
+		`        - id`,
+		`        - owner`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/DescribedStruct'`,
+	})
+
+	opt := NewOptions()
+	opt.DeReference = true
+	gotStrings, _ = NewJSONSchemaRenderer(opt).ProcessResult(schema)
+	compareStrings(t, "description: json schema", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "properties": {`,
+		`    "id": {`,
+		`      "description": "Unique ID, assigned by the server.",`,
+		`      "type": "string"`,
+		`    },`,
+		`    "owner": {`,
+		`      "description": "Owner of the record.",`,
+		`      "properties": {`,
+		`        "name": {`,
+		`          "description": "Full name.\nMay span lines.",`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "name"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    }`,
+		`  },`,
+		`  "required": [`,
+		`    "id",`,
+		`    "owner"`,
+		`  ],`,
+		`  "type": "object"`,
+		`}`,
+	})
+
+	// A description next to a reference wraps the reference in allOf.
+	gotStrings, _ = NewJSONSchemaRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "description: json schema references", gotStrings, []string{
+		`{`,
+		`  "$schema": "http://json-schema.org/draft-07/schema#",`,
+		`  "allOf": [`,
+		`    {`,
+		`      "$ref": "#/definitions/DescribedStruct"`,
+		`    }`,
+		`  ],`,
+		`  "definitions": {`,
+		`    "DescribedOwner": {`,
+		`      "properties": {`,
+		`        "name": {`,
+		`          "description": "Full name.\nMay span lines.",`,
+		`          "type": "string"`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "name"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    },`,
+		`    "DescribedStruct": {`,
+		`      "properties": {`,
+		`        "id": {`,
+		`          "description": "Unique ID, assigned by the server.",`,
+		`          "type": "string"`,
+		`        },`,
+		`        "owner": {`,
+		`          "allOf": [`,
+		`            {`,
+		`              "$ref": "#/definitions/DescribedOwner"`,
+		`            }`,
+		`          ],`,
+		`          "description": "Owner of the record."`,
+		`        }`,
+		`      },`,
+		`      "required": [`,
+		`        "id",`,
+		`        "owner"`,
+		`      ],`,
+		`      "type": "object"`,
+		`    }`,
+		`  }`,
+		`}`,
+	})
+}
+
+// BytesStruct has a byte slice and a signed byte slice.
+type BytesStruct struct {
+	Data   []byte `json:"data"`
+	Signed []int8 `json:"signed"`
+}
+
+func TestOpenAPIRenderer_Bytes(t *testing.T) {
+	r := reflector.NewReflector()
+	schema := r.DeriveSchema(BytesStruct{})
+
+	// Byte slices are base64 strings. Other numeric slices are arrays.
+	gotStrings, _ := NewOpenAPIRenderer("/test/path", nil).ProcessResult(schema)
+	compareStrings(t, "bytes: openapi", gotStrings, []string{
+		`openapi: 3.0.0`,
+		`components:`,
+		`  schemas:`,
+		`    BytesStruct:`,
+		`      type: object`,
+		`      properties:`,
+		`        data:`,
+		`          type: string`,
+		`          format: byte`,
+		`        signed:`,
+		`          type: array`,
+		`          items:`,
+		`            type: integer`,
+		`      required:`,
+		`        - data`,
+		`        - signed`,
+		`paths:`,
+		`  /test/path:`,
+		`    get:`,
+		`      summary: Return data.`,
+		`      responses:`,
+		`        "200":`,
+		`          description: Success`,
+		`          content:`,
+		`            application/json:`,
+		`              schema:`,
+		`                $ref: '#/components/schemas/BytesStruct'`,
+	})
+
+	dataElem := schema.TypeRefs.ChildByName("BytesStruct", nil).ChildByName("Data", nil)
+	if got, _ := dataElem.NativeDefault().Options.Get("Encoding"); got != "base64" {
+		t.Errorf("TEST_FAIL bytes: Encoding: got %q, want %q", got, "base64")
+	}
+}
+
+func TestGoRenderer(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(BasicStruct{})
+
+	gotStrings, _ := NewGoRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "go: struct", gotStrings, []string{
+		"package schema",
+		"",
+		"type BasicStruct struct {",
+		"\tBoolVal    bool    `json:\"BoolVal\"`",
+		"\tFloat64Val float64 `json:\"Float64Val\"`",
+		"\tIntVal     int     `json:\"IntVal\"`",
+		"\tStringVal  string  `json:\"StringVal\"`",
+		"}",
+		"",
+		"type Root = BasicStruct",
+	})
+
+	// Anonymous roots are declared as Root.
+	rootValue := struct {
+		ID      string            `json:"id"`
+		Created *time.Time        `json:"created,omitempty"`
+		Owner   *BasicStruct      `json:"owner"`
+		Tags    []string          `json:"tags"`
+		Labels  map[string]string `json:"labels"`
+		Address struct {
+			Street string `json:"street"`
+		} `json:"address"`
+	}{}
+	gotStrings, _ = NewGoRenderer(nil).ProcessResult(reflector.NewReflector().DeriveSchema(rootValue))
+	compareStrings(t, "go: anonymous root", gotStrings, []string{
+		"package schema",
+		"",
+		"import \"time\"",
+		"",
+		"type BasicStruct struct {",
+		"\tBoolVal    bool    `json:\"BoolVal\"`",
+		"\tFloat64Val float64 `json:\"Float64Val\"`",
+		"\tIntVal     int     `json:\"IntVal\"`",
+		"\tStringVal  string  `json:\"StringVal\"`",
+		"}",
+		"",
+		"type Root struct {",
+		"\tAddress struct {",
+		"\t\tStreet string `json:\"street\"`",
+		"\t} `json:\"address\"`",
+		"\tCreated *time.Time        `json:\"created,omitempty\"`",
+		"\tID      string            `json:\"id\"`",
+		"\tLabels  map[string]string `json:\"labels\"`",
+		"\tOwner   *BasicStruct      `json:\"owner\"`",
+		"\tTags    []string          `json:\"tags\"`",
+		"}",
+	})
+}
+
+func TestGoRenderer_TypeRefErrors(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(CompoundTypes{})
+
+	// PrivateStruct has no exported fields but the PrivatePtr field still needs its type.
+	gotStrings, _ := NewGoRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "go: typeref errors", gotStrings, []string{
+		"package schema",
+		"",
+		"type CompoundTypes struct {",
+		"\tArray0     [0]string      `json:\"Array0\"`",
+		"\tArray3     [3]string      `json:\"Array3\"`",
+		"\t// Interface: interface element is nil",
+		"\t// Map: map key type must be string",
+		"\tPrivatePtr *PrivateStruct `json:\"PrivatePtr\"`",
+		"\tPtr        *StringStruct  `json:\"Ptr\"`",
+		"\tSlice      []interface{}  `json:\"Slice\"`",
+		"\t// Struct: empty struct not supported",
+		"}",
+		"",
+		"// PrivateStruct: struct has no exported fields",
+		"type PrivateStruct interface{}",
+		"",
+		"type StringStruct struct {",
+		"\tValue string `json:\"Value\"`",
+		"}",
+		"",
+		"type Root = CompoundTypes",
+	})
+}
+
+func TestJSONRenderer_ErrorMarkers(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(CompoundTypes{})
+
+	gotStrings, _ := NewJSONRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "CompoundTypes: dialect=json", gotStrings, []string{
+		`definitions.CompoundTypes:{}`,
+		`definitions.CompoundTypes:{}.Array0:[]`,
+		`definitions.CompoundTypes:{}.Array0:[].string`,
+		`definitions.CompoundTypes:{}.Array3:[]`,
+		`definitions.CompoundTypes:{}.Array3:[].string`,
+		`definitions.CompoundTypes:{}.!Interface:invalid! ERROR:interface element is nil`,
+		`definitions.CompoundTypes:{}.!Map:{}! ERROR:map key type must be string`,
+		`definitions.CompoundTypes:{}.PrivatePtr:{}:PrivateStruct`,
+		`definitions.CompoundTypes:{}.Ptr:{}:StringStruct`,
+		`definitions.CompoundTypes:{}.Slice:[]`,
+		`definitions.CompoundTypes:{}.Slice:[].!invalid! ERROR:interface element is nil`,
+		`definitions.CompoundTypes:{}.!Struct:{}! ERROR:empty struct not supported`,
+		`definitions.!PrivateStruct:{}! ERROR:struct has no exported fields`,
+		`definitions.StringStruct:{}`,
+		`definitions.StringStruct:{}.Value:string`,
+		`$.{}:CompoundTypes`,
+	})
+
+	for _, line := range gotStrings {
+		if strings.Contains(line, "!!") {
+			t.Errorf("TEST_FAIL CompoundTypes: double error marker in %s", line)
+		}
+	}
+}
+
+// ProtoDuplicateNumberStruct has two fields with the same protobuf tag number.
+type ProtoDuplicateNumberStruct struct {
+	ID   string `protobuf:"1"`
+	Name string `protobuf:"1"`
+}
+
+func TestProtoRenderer_DuplicateNumbers(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(ProtoDuplicateNumberStruct{})
+
+	gotStrings, err := NewProtoRenderer(nil).ProcessResult(schema)
+	compareStrings(t, "proto: duplicate numbers", gotStrings, []string{
+		`syntax = "proto3";`,
+		``,
+		`message ProtoDuplicateNumberStruct {`,
+		`  // error: fields "ID" and "Name" have the same protobuf field number 1`,
+		`}`,
+	})
+
+	elemErrs, ok := err.(ElementErrors)
+	if !ok {
+		t.Fatalf("TEST_FAIL proto: duplicate numbers: got err=%v, want ElementErrors", err)
+	}
+	compareStrings(t, "proto: duplicate numbers: errors", elemErrs, []string{
+		`TypeRefs.ProtoDuplicateNumberStruct:{} ERROR:fields "ID" and "Name" have the same protobuf field number 1`,
+	})
+}
+
+func TestOpenAPIRenderer_RenderSchema(t *testing.T) {
+	schema := reflector.NewReflector().DeriveSchema(AnyStruct{})
+	render := NewOpenAPIRenderer("/test/path", nil)
+
+	// RenderSchema returns the document without the version line.
+	gotStrings := RenderSchema(schema, render)
+	wantStrings, _ := render.ProcessResult(schema)
+	compareStrings(t, "openapi: RenderSchema", gotStrings, wantStrings[1:])
+
+	// RenderType returns a single element as it appears in the document.
+	nameElem := schema.TypeRefs.Children[0].ChildByName("Name", nil)
+	compareStrings(t, "openapi: RenderType", RenderType(nameElem, render), []string{
+		`name:`,
+		`  type: string`,
+	})
+}
@@ -10,6 +10,8 @@ import (
 
 // SimpleRenderer provides a simple string renderer.
 type SimpleRenderer struct {
+	sharedAnalysis
+
 	opt *Options
 }
 
@@ -23,7 +25,7 @@ func NewSimpleRenderer(opt *Options) *SimpleRenderer {
 
 func (r *SimpleRenderer) ProcessResult(result *types.Schema) ([]string, error) {
 	// Header
-	return RenderSchema(result, r), nil
+	return appendStrings(bannerLines(r.opt, "#"), RenderSchema(result, r)), rendererErrors(r.analyze(result), r, nil).err()
 	// Footer
 }
 
@@ -31,6 +33,14 @@ func (r *SimpleRenderer) DeReference() bool {
 	return r.opt.DeReference
 }
 
+func (r *SimpleRenderer) OmitErrors() bool {
+	return r.opt.OmitErrors
+}
+
+func (r *SimpleRenderer) TypeRenderer(typeName string) TypeRendererFunc {
+	return r.opt.TypeRenderers[typeName]
+}
+
 func (r *SimpleRenderer) Indent() int {
 	return r.opt.Indent
 }
@@ -86,7 +96,7 @@ func (r *SimpleRenderer) Path(t *types.TypeElement) []string {
 	if t.TypeCategory == typecategory.Invalid.String() {
 		typePart = t.Type
 	} else {
-		typePart = generictype.PathDefaultOfType(t.Type)
+		typePart = t.PathDefault()
 	}
 
 	// Add TypeRef suffix if set but not if de-referencing.
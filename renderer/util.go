@@ -1,9 +1,126 @@
 package renderer
 
 import (
+	"fmt"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
 	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"strings"
 )
 
+// ElementErrors lists errors found on schema elements. Each entry is the element path followed by its error.
+type ElementErrors []string
+
+func (e ElementErrors) Error() string {
+	return fmt.Sprintf("schema has %d element error(s): %s", len(e), strings.Join(e, "; "))
+}
+
+// err returns e as an error or nil if e is empty.
+func (e ElementErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// SchemaErrors returns an ElementErrors for all elements in the schema that have errors.
+// - Cyclical references are not included because renderers keep them as references.
+// - Returns nil if no errors are found.
+func SchemaErrors(schema *types.Schema) error {
+	return analyzeSchema(schema).elementErrors(nil).err()
+}
+
+// rendererErrors returns the element errors that ProcessResult of a renderer reports.
+// - With OmitErrors, elements with errors are left out of the output so no errors are reported.
+// - If ignore is not nil, errors of elements for which it returns true are not reported, e.g. errors that a renderer resolves.
+func rendererErrors(a *schemaAnalysis, r Renderer, ignore func(t *types.TypeElement) bool) ElementErrors {
+	if omitter, ok := r.(errorOmitter); ok && omitter.OmitErrors() {
+		return ElementErrors{}
+	}
+	return a.elementErrors(ignore)
+}
+
+// schemaAnalysis holds what renderers look up about a Schema as a whole: TypeRefs by name, cycles and element errors.
+// - RenderAll computes it once and shares it with all renderers. Renderers must not change it.
+type schemaAnalysis struct {
+	schema *types.Schema
+
+	// typeRefs maps TypeRef names to their elements.
+	typeRefs map[string]*types.TypeElement
+
+	// cycles is true if the Root tree has cyclical references.
+	cycles bool
+
+	// errElems are the elements with errors in path order. errLines are their error lines.
+	errElems []*types.TypeElement
+	errLines [][]string
+}
+
+// analyzeSchema walks a Schema once to build its schemaAnalysis.
+func analyzeSchema(schema *types.Schema) *schemaAnalysis {
+	a := &schemaAnalysis{
+		schema:   schema,
+		typeRefs: schema.TypeRefs.ChildMap(),
+	}
+
+	// Error lines use the de-referenced form. The Root tree contains all reflected elements so TypeRefs do not need to be checked.
+	opt := NewOptions()
+	opt.DeReference = true
+	r := NewSimpleRenderer(opt)
+
+	var walk func(t *types.TypeElement)
+	walk = func(t *types.TypeElement) {
+		if t.Error == types.CyclicalReferenceErr {
+			a.cycles = true
+		} else if t.Error != "" {
+			a.errElems = append(a.errElems, t)
+			a.errLines = append(a.errLines, r.Pre(t))
+		}
+
+		typeRefMap := t.ChildMap()
+		for _, childName := range t.ChildKeys(typeRefMap) {
+			walk(typeRefMap[childName])
+		}
+	}
+	walk(schema.Root)
+
+	return a
+}
+
+// elementErrors returns the error lines of elements with errors.
+// - If ignore is not nil, errors of elements for which it returns true are not returned.
+func (a *schemaAnalysis) elementErrors(ignore func(t *types.TypeElement) bool) ElementErrors {
+	errs := ElementErrors{}
+	for i, t := range a.errElems {
+		if ignore == nil || !ignore(t) {
+			errs = append(errs, a.errLines[i]...)
+		}
+	}
+	return errs
+}
+
+// sharedAnalysis is embedded in renderers to use an analysis shared by RenderAll.
+type sharedAnalysis struct {
+	analysis *schemaAnalysis
+}
+
+// shareAnalysis sets the analysis to use for its Schema. A nil analysis stops sharing.
+func (s *sharedAnalysis) shareAnalysis(a *schemaAnalysis) {
+	s.analysis = a
+}
+
+// analyze returns the shared analysis of a Schema, or a new analysis if none is shared.
+func (s *sharedAnalysis) analyze(schema *types.Schema) *schemaAnalysis {
+	if s.analysis != nil && s.analysis.schema == schema {
+		return s.analysis
+	}
+	return analyzeSchema(schema)
+}
+
+// analysisSharer is implemented by renderers that can use a shared schemaAnalysis.
+type analysisSharer interface {
+	shareAnalysis(a *schemaAnalysis)
+}
+
 // RenderStrings builds a string representation of a type result using the given pre, path, and post functions.
 func RenderSchema(schema *types.Schema, r Renderer) []string {
 	// Build output outLines.
@@ -35,18 +152,103 @@ func RenderSchema(schema *types.Schema, r Renderer) []string {
 	return out
 }
 
+// bannerLines returns the banner of opt as comment lines that start with commentPrefix, e.g. "#" or "//".
+func bannerLines(opt *Options, commentPrefix string) []string {
+	if opt.Banner == "" {
+		return []string{}
+	}
+
+	out := []string{}
+	for _, line := range strings.Split(opt.Banner, "\n") {
+		if line == "" {
+			out = append(out, commentPrefix)
+		} else {
+			out = append(out, commentPrefix+" "+line)
+		}
+	}
+	return out
+}
+
+// TypeRendererFunc renders a TypeElement and its children with fully custom output.
+type TypeRendererFunc func(t *types.TypeElement, r Renderer) []string
+
+// typeRendererSource is implemented by renderers that have custom render functions, see Options.RegisterTypeRenderer.
+type typeRendererSource interface {
+	// TypeRenderer returns the custom render function for a TypeRef name or nil if there is none.
+	TypeRenderer(typeName string) TypeRendererFunc
+}
+
+// typeRendererOf returns the custom render function of a renderer for an element or nil if there is none.
+func typeRendererOf(t *types.TypeElement, r Renderer) TypeRendererFunc {
+	source, ok := r.(typeRendererSource)
+	if !ok {
+		return nil
+	}
+
+	typeName := t.NativeDefault().TypeRef
+	if typeName == "" && t.Parent != nil && t.Parent.Type == generictype.Root.String() && t.Parent.Name == "TypeRefs" {
+		typeName = t.Name
+	}
+	if typeName == "" {
+		return nil
+	}
+
+	return source.TypeRenderer(typeName)
+}
+
+// elementSkipper is implemented by renderers that render some elements outside of RenderType.
+type elementSkipper interface {
+	// Skip returns true if an element and its children are not rendered by RenderType.
+	Skip(t *types.TypeElement) bool
+}
+
+// errorOmitter is implemented by renderers that can drop elements with errors.
+type errorOmitter interface {
+	// OmitErrors returns true if elements with errors are not rendered.
+	OmitErrors() bool
+}
+
+// childRenderer is implemented by renderers whose Pre renders the children of an element.
+type childRenderer interface {
+	rendersChildren() bool
+}
+
+// skipElement returns true if RenderType does not render an element.
+func skipElement(t *types.TypeElement, r Renderer) bool {
+	if omitter, ok := r.(errorOmitter); ok && omitter.OmitErrors() && t.Error != "" && t.Error != types.CyclicalReferenceErr {
+		return true
+	}
+	if skipper, ok := r.(elementSkipper); ok && skipper.Skip(t) {
+		return true
+	}
+	return false
+}
+
 // RenderType builds strings for a TypeElement and its children.
 func RenderType(t *types.TypeElement, r Renderer) []string {
+	if skipElement(t, r) {
+		return []string{}
+	}
+
 	// Capture initial indent and restore on exit.
 	originalIndent := r.Indent()
 
+	// Custom render functions replace default handling.
+	if fn := typeRendererOf(t, r); fn != nil {
+		out := appendStrings([]string{}, fn(t, r))
+		r.SetIndent(originalIndent)
+		return out
+	}
+
 	out := []string{}
 
 	// Process element with preFunc.
 	out = appendStrings(out, r.Pre(t))
 
 	// Process children.
-	if !r.DeReference() && t.TypeRef != "" {
+	if cr, ok := r.(childRenderer); ok && cr.rendersChildren() {
+		// Children are rendered by Pre.
+	} else if !r.DeReference() && t.TypeRef != "" {
 		// Skip children.
 	} else {
 		// Always process children in alphabetical order.
@@ -75,6 +277,85 @@ func RenderType(t *types.TypeElement, r Renderer) []string {
 	return out
 }
 
+// inlineOptions are tag options that inline a field in its parent object.
+// - "inline" is used by json, "squash" and "remain" are used by mapstructure.
+var inlineOptions = []string{"inline", "squash", "remain"}
+
+// isCatchAll returns true if an element is a map field with an inline option in the dialect.
+// - A catch-all field holds the properties of its parent object that are not known fields.
+func isCatchAll(t *types.TypeElement, dialect string) bool {
+	if t.Parent == nil || t.Parent.Type != generictype.Struct.String() || t.NativeDefault().Type != "map" {
+		return false
+	}
+
+	nativeType := t.GetNativeType(dialect)
+	for _, option := range inlineOptions {
+		if _, ok := nativeType.Options.Get(option); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isInternal returns true if an element has the "internal" option in the dialect.
+// - Internal fields are part of the data but hidden from public documentation.
+func isInternal(t *types.TypeElement, dialect string) bool {
+	_, ok := t.GetNativeType(dialect).Options.Get("internal")
+	return ok
+}
+
+// catchAllOf returns the catch-all field of a struct element or nil if there is none.
+func catchAllOf(t *types.TypeElement, dialect string) *types.TypeElement {
+	for _, childElem := range t.Children {
+		if isCatchAll(childElem, dialect) {
+			return childElem
+		}
+	}
+	return nil
+}
+
+// isMap returns true if a struct element is a map typed by its values.
+// - Map values are a single unnamed child element. Struct fields are always named.
+func isMap(t *types.TypeElement) bool {
+	return t.Type == generictype.Struct.String() && len(t.Children) == 1 && t.Children[0].Name == ""
+}
+
+// isRequired returns true if an element must be present in its parent object.
+// - Nullable elements (pointers, interfaces) are optional.
+// - Interfaces are optional even if they are not nullable, e.g. nil interfaces, see isInterface.
+// - Elements with the "omitempty" option in the dialect are optional.
+func isRequired(t *types.TypeElement, dialect string) bool {
+	if t.Nullable || isInterface(t) {
+		return false
+	}
+
+	return !hasOmitEmpty(t, dialect)
+}
+
+// isInterface returns true if an element was reflected from a Go interface.
+// - Nil interfaces have no type. They have the NilInterfaceErr error or, if the error is in their TypeRef, the "interface" native type.
+func isInterface(t *types.TypeElement) bool {
+	return t.Type == generictype.Interface.String() || t.Error == types.NilInterfaceErr || t.NativeDefault().Type == "interface"
+}
+
+// isUnion returns true if an interface element is a union of its children.
+func isUnion(t *types.TypeElement) bool {
+	val, _ := t.NativeDefault().Options.Get("Union")
+	return val == "oneOf"
+}
+
+// isUnsigned returns true if an integer element was reflected from an unsigned Go type.
+func isUnsigned(t *types.TypeElement) bool {
+	val, _ := t.NativeDefault().Options.Get("Unsigned")
+	return val == "true"
+}
+
+// hasOmitEmpty returns true if an element has the "omitempty" option in the dialect.
+func hasOmitEmpty(t *types.TypeElement, dialect string) bool {
+	_, ok := t.GetNativeType(dialect).Options.Get("omitempty")
+	return ok
+}
+
 // appendStrings adds non-empty strings from in to out and returns a new slice.
 func appendStrings(out []string, in []string) []string {
 	for _, s := range in {
@@ -84,3 +365,55 @@ func appendStrings(out []string, in []string) []string {
 	}
 	return out
 }
+
+// RenderSpec names a Renderer for RenderAll.
+type RenderSpec struct {
+	Name     string
+	Renderer Renderer
+}
+
+// RenderAll runs each renderer on the same schema and returns the output of each keyed by RenderSpec.Name.
+// - The schema is analyzed once (TypeRefs, cycles, element errors) and the analysis is shared by the built-in renderers.
+// - All renderers are run even if some return errors.
+// - Element errors are the same for all renderers and are only reported once.
+func RenderAll(schema *types.Schema, specs []RenderSpec) (map[string][]string, error) {
+	out := map[string][]string{}
+	analysis := analyzeSchema(schema)
+
+	errs := ElementErrors{}
+	seenErrs := map[string]bool{}
+
+	for _, spec := range specs {
+		if _, ok := out[spec.Name]; ok {
+			return nil, fmt.Errorf("duplicate render spec name %q", spec.Name)
+		}
+
+		sharer, ok := spec.Renderer.(analysisSharer)
+		if ok {
+			sharer.shareAnalysis(analysis)
+		}
+		lines, err := spec.Renderer.ProcessResult(schema)
+		if ok {
+			sharer.shareAnalysis(nil)
+		}
+		out[spec.Name] = lines
+
+		if err == nil {
+			continue
+		}
+
+		elemErrs, ok := err.(ElementErrors)
+		if !ok {
+			return nil, fmt.Errorf("render spec %q: %w", spec.Name, err)
+		}
+
+		for _, e := range elemErrs {
+			if !seenErrs[e] {
+				seenErrs[e] = true
+				errs = append(errs, e)
+			}
+		}
+	}
+
+	return out, errs.err()
+}
@@ -0,0 +1,73 @@
+package renderer
+
+import (
+	"bytes"
+	"github.com/gitmann/b9schema-reflector-golang/lib/enum/generictype"
+	"github.com/gitmann/b9schema-reflector-golang/lib/types"
+	"gopkg.in/yaml.v3"
+)
+
+// YAML documents are built as yaml.Node trees so mappings keep their order and the encoder quotes keys and values as needed.
+
+// encodeYAML returns a YAML document with an indent of 2 spaces.
+func encodeYAML(doc *yaml.Node) ([]byte, error) {
+	var b bytes.Buffer
+
+	e := yaml.NewEncoder(&b)
+	e.SetIndent(2)
+	if err := e.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := e.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// yamlMap returns an empty mapping.
+func yamlMap() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode}
+}
+
+// yamlSeq returns a sequence of items.
+func yamlSeq(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Content: items}
+}
+
+// yamlFlowSeq returns a sequence of strings on a single line, e.g. "[string, "null"]".
+func yamlFlowSeq(values ...string) *yaml.Node {
+	out := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, val := range values {
+		out.Content = append(out.Content, yamlString(val))
+	}
+	return out
+}
+
+// yamlAdd adds a key and value to a mapping.
+func yamlAdd(m *yaml.Node, key string, val *yaml.Node) {
+	m.Content = append(m.Content, yamlString(key), val)
+}
+
+// yamlString returns a string scalar. The encoder quotes it if it would be read as another type, e.g. "200" or "null".
+func yamlString(val string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: val}
+}
+
+// yamlQuoted returns a double-quoted string scalar.
+func yamlQuoted(val string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: val, Style: yaml.DoubleQuotedStyle}
+}
+
+// yamlScalar returns a scalar whose type is resolved from its value, e.g. numbers and booleans.
+func yamlScalar(val string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: val}
+}
+
+// yamlValue returns a YAML scalar for a value of an element. String values are quoted.
+func yamlValue(t *types.TypeElement, val string) *yaml.Node {
+	if t.SchemaType() == generictype.String.String() {
+		return yamlQuoted(val)
+	}
+	return yamlScalar(val)
+}